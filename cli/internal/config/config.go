@@ -12,6 +12,7 @@ import (
 
 	"github.com/vercel/turborepo/cli/internal/client"
 	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/packagemanager"
 
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/kelseyhightower/envconfig"
@@ -91,10 +92,9 @@ func ParseAndValidate(args []string, ui cli.Ui, turboVersion string) (c *Config,
 		return nil, err
 	}
 	// Precedence is flags > env > config > default
-	packageJSONPath := cwd.Join("package.json")
-	rootPackageJSON, err := fs.ReadPackageJSON(packageJSONPath.ToStringDuringMigration())
+	rootPackageJSON, err := packagemanager.ReadRootManifest(cwd)
 	if err != nil {
-		return nil, fmt.Errorf("package.json: %w", err)
+		return nil, err
 	}
 	userConfig, err := ReadUserConfigFile()
 	if err != nil {
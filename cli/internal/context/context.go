@@ -130,7 +130,7 @@ func WithGraph(config *config.Config, turboJSON *fs.TurboJSON, cacheDir fs.Absol
 		c.PackageInfos = make(map[interface{}]*fs.PackageJSON)
 		c.RootNode = core.ROOT_NODE_NAME
 
-		if packageManager, err := packagemanager.GetPackageManager(config.Cwd, config.RootPackageJSON); err != nil {
+		if packageManager, err := packagemanager.GetPackageManagerWithOpts(config.Cwd, config.RootPackageJSON, packagemanager.Opts{Logger: config.Logger}); err != nil {
 			return err
 		} else {
 			c.PackageManager = packageManager
@@ -16,7 +16,9 @@ type PackageJSON struct {
 	OptionalDependencies   map[string]string `json:"optionalDependencies,omitempty"`
 	PeerDependencies       map[string]string `json:"peerDependencies,omitempty"`
 	PackageManager         string            `json:"packageManager,omitempty"`
+	PackageManagers        []string          `json:"-"` // set instead of PackageManager when the field is an array
 	Os                     []string          `json:"os,omitempty"`
+	Engines                map[string]string `json:"engines,omitempty"`
 	Workspaces             Workspaces        `json:"workspaces,omitempty"`
 	Private                bool              `json:"private,omitempty"`
 	PackageJSONPath        string
@@ -36,12 +38,20 @@ type WorkspacesAlt struct {
 	Packages []string `json:"packages,omitempty"`
 }
 
+// UnmarshalJSON decodes workspaces from an array of globs or an object with a
+// packages array. A malformed single glob string is treated as a one-element
+// array.
 func (r *Workspaces) UnmarshalJSON(data []byte) error {
 	var tmp = &WorkspacesAlt{}
 	if err := json.Unmarshal(data, tmp); err == nil {
 		*r = Workspaces(tmp.Packages)
 		return nil
 	}
+	var glob string
+	if err := json.Unmarshal(data, &glob); err == nil {
+		*r = Workspaces{glob}
+		return nil
+	}
 	var tempstr = []string{}
 	if err := json.Unmarshal(data, &tempstr); err != nil {
 		return err
@@ -50,6 +60,29 @@ func (r *Workspaces) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// packageJSONFields has the same fields as PackageJSON, but none of its methods,
+// so that it can be decoded without recursing into PackageJSON.UnmarshalJSON.
+type packageJSONFields PackageJSON
+
+// UnmarshalJSON decodes package.json, accepting either a single string or an
+// array of strings for the packageManager field.
+func (p *PackageJSON) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*packageJSONFields
+		PackageManager json.RawMessage `json:"packageManager,omitempty"`
+	}{packageJSONFields: (*packageJSONFields)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.PackageManager) == 0 || string(aux.PackageManager) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(aux.PackageManager, &p.PackageManager); err == nil {
+		return nil
+	}
+	return json.Unmarshal(aux.PackageManager, &p.PackageManagers)
+}
+
 // Parse parses package.json payload and returns structure.
 func Parse(payload []byte) (*PackageJSON, error) {
 	var packagejson *PackageJSON
@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParse_PackageManager(t *testing.T) {
+	tests := []struct {
+		name                string
+		payload             string
+		wantPackageManager  string
+		wantPackageManagers []string
+	}{
+		{
+			name:               "string",
+			payload:            `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+			wantPackageManager: "pnpm@8.6.0",
+		},
+		{
+			name:                "array",
+			payload:             `{"name": "root", "packageManager": ["pnpm@8.6.0", "npm@9.0.0"]}`,
+			wantPackageManagers: []string{"pnpm@8.6.0", "npm@9.0.0"},
+		},
+		{
+			name:    "absent",
+			payload: `{"name": "root"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := Parse([]byte(tt.payload))
+			assert.NilError(t, err, "Parse")
+			assert.Equal(t, pkg.Name, "root")
+			assert.Equal(t, pkg.PackageManager, tt.wantPackageManager)
+			assert.DeepEqual(t, pkg.PackageManagers, tt.wantPackageManagers)
+		})
+	}
+}
+
+func TestParse_Workspaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    Workspaces
+	}{
+		{
+			name:    "array",
+			payload: `{"workspaces": ["apps/*", "packages/*"]}`,
+			want:    Workspaces{"apps/*", "packages/*"},
+		},
+		{
+			name:    "object",
+			payload: `{"workspaces": {"packages": ["packages/*"], "nohoist": ["**/react-native"]}}`,
+			want:    Workspaces{"packages/*"},
+		},
+		{
+			name:    "string",
+			payload: `{"workspaces": "packages/*"}`,
+			want:    Workspaces{"packages/*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := Parse([]byte(tt.payload))
+			assert.NilError(t, err, "Parse")
+			assert.DeepEqual(t, pkg.Workspaces, tt.want)
+		})
+	}
+}
+
+func TestParse_InvalidPackageManager(t *testing.T) {
+	_, err := Parse([]byte(`{"packageManager": 8}`))
+	assert.ErrorContains(t, err, "cannot unmarshal")
+}
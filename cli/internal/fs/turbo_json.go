@@ -21,6 +21,8 @@ type TurboJSON struct {
 	Pipeline Pipeline
 	// Configuration options when interfacing with the remote cache
 	RemoteCacheOptions RemoteCacheOptions `json:"remoteCache,omitempty"`
+	// Workspace globs used when the package manager's own configuration defines none
+	ExperimentalWorkspaces []string `json:"experimentalWorkspaces,omitempty"`
 }
 
 // ReadTurboConfig toggles between reading from package.json or turbo.json to support early adopters.
@@ -103,12 +103,9 @@ func (tr *testResult) getTestLogin() login {
 
 func newTest(t *testing.T, redirectedURL string) *testResult {
 	stepCh := make(chan struct{}, 1)
-	cwd, err := fs.GetCwd()
-	if err != nil {
-		t.Fatalf("getting cwd: %v", err)
-	}
+	// Keep the repo config the tests write out of the source tree.
 	tr := &testResult{
-		repoRoot: cwd,
+		repoRoot: fs.AbsolutePathFromUpstream(t.TempDir()),
 		stepCh:   stepCh,
 	}
 	tr.client.team = &client.Team{
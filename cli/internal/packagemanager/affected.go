@@ -0,0 +1,98 @@
+package packagemanager
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// rootConfigFiles are the files at the repository root which can change the
+// behavior of every workspace, in addition to the package manager's lockfile.
+var rootConfigFiles = []string{"package.json", "turbo.json", "pnpm-workspace.yaml", ".npmrc", ".yarnrc", ".yarnrc.yml"}
+
+// AffectedWorkspaces returns the names of the workspaces owning any of
+// changedFiles, together with every workspace which depends on them through
+// internal dependencies, as found by BuildWorkspaceGraph, sorted by name.
+// changedFiles may be absolute or relative to rootpath. A change to root
+// configuration, such as the root package.json or the lockfile, affects every
+// workspace; any other file outside every workspace affects none.
+func (pm PackageManager) AffectedWorkspaces(rootpath fs.AbsolutePath, changedFiles []string) ([]string, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	lockfilePath := pm.LockfilePath(rootpath)
+	owners := make(map[string]bool)
+	for _, changedFile := range changedFiles {
+		relativePath, err := filepath.Rel(rootpath.ToStringDuringMigration(), fs.ResolveUnknownPath(rootpath, changedFile).ToStringDuringMigration())
+		if err != nil {
+			return nil, err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		if rootpath.Join(relativePath) == lockfilePath || isRootConfigFile(relativePath) {
+			return workspaceNames(workspaces), nil
+		}
+		if owner := owningWorkspace(workspaces, relativePath); owner != nil {
+			owners[owner.Name] = true
+		}
+	}
+	if len(owners) == 0 {
+		return []string{}, nil
+	}
+
+	graph, err := pm.buildWorkspaceGraph(rootpath, workspaces)
+	if err != nil {
+		return nil, err
+	}
+
+	affected := make(map[string]bool, len(owners))
+	queue := make([]string, 0, len(owners))
+	for name := range owners {
+		affected[name] = true
+		queue = append(queue, name)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dependent := range graph.Dependents(name) {
+			if !affected[dependent] {
+				affected[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(affected))
+	for name := range affected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// isRootConfigFile reports whether the slash-separated relativePath is root
+// configuration, other than the lockfile, which affects every workspace.
+func isRootConfigFile(relativePath string) bool {
+	if strings.Contains(relativePath, "/") {
+		return false
+	}
+	for _, name := range rootConfigFiles {
+		if relativePath == name {
+			return true
+		}
+	}
+	return false
+}
+
+func workspaceNames(workspaces []WorkspacePackage) []string {
+	names := make([]string, len(workspaces))
+	for i, workspace := range workspaces {
+		names[i] = workspace.Name
+	}
+	sort.Strings(names)
+	return names
+}
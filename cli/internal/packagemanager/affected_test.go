@@ -0,0 +1,104 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// chainNpmLockfile describes web -> ui -> config, with docs depending on nothing.
+const chainNpmLockfile = `{
+  "name": "root",
+  "lockfileVersion": 2,
+  "packages": {
+    "": {"name": "root", "workspaces": ["apps/*", "packages/*"]},
+    "apps/web": {"name": "web", "dependencies": {"ui": "*", "react": "^18.0.0"}},
+    "apps/docs": {"name": "docs"},
+    "packages/ui": {"name": "ui", "dependencies": {"config": "*"}},
+    "packages/config": {"name": "config"},
+    "node_modules/ui": {"resolved": "packages/ui", "link": true},
+    "node_modules/config": {"resolved": "packages/config", "link": true},
+    "node_modules/react": {"version": "18.2.0"}
+  }
+}`
+
+func TestAffectedWorkspaces(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"package-lock.json":            chainNpmLockfile,
+		"apps/web/package.json":        `{"name": "web"}`,
+		"apps/docs/package.json":       `{"name": "docs"}`,
+		"packages/ui/package.json":     `{"name": "ui"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	tests := []struct {
+		name         string
+		changedFiles []string
+		want         []string
+	}{
+		{
+			name:         "leaf workspace",
+			changedFiles: []string{"apps/web/src/index.ts"},
+			want:         []string{"web"},
+		},
+		{
+			name:         "dependency chain",
+			changedFiles: []string{"packages/config/index.js"},
+			want:         []string{"config", "ui", "web"},
+		},
+		{
+			name:         "absolute path",
+			changedFiles: []string{rootPath.Join("packages", "ui", "button.tsx").ToStringDuringMigration()},
+			want:         []string{"ui", "web"},
+		},
+		{
+			name:         "multiple workspaces",
+			changedFiles: []string{"apps/docs/README.md", "packages/ui/button.tsx"},
+			want:         []string{"docs", "ui", "web"},
+		},
+		{
+			name:         "outside every workspace",
+			changedFiles: []string{"README.md", "scripts/release.sh"},
+			want:         []string{},
+		},
+		{
+			name:         "root package.json",
+			changedFiles: []string{"apps/docs/README.md", "package.json"},
+			want:         []string{"config", "docs", "ui", "web"},
+		},
+		{
+			name:         "lockfile",
+			changedFiles: []string{"package-lock.json"},
+			want:         []string{"config", "docs", "ui", "web"},
+		},
+		{
+			name:         "nested file named like root config",
+			changedFiles: []string{"scripts/turbo.json"},
+			want:         []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nodejsNpm.AffectedWorkspaces(rootPath, tt.changedFiles)
+			assert.NilError(t, err, "AffectedWorkspaces")
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestAffectedWorkspaces_YarnClassic(t *testing.T) {
+	// yarn.lock cannot be read, so internal dependencies come from manifests.
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"yarn.lock":                    "# yarn lockfile v1\n",
+		"apps/web/package.json":        `{"name": "web", "dependencies": {"ui": "*"}}`,
+		"apps/docs/package.json":       `{"name": "docs"}`,
+		"packages/ui/package.json":     `{"name": "ui", "devDependencies": {"config": "*"}}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	got, err := nodejsYarn.AffectedWorkspaces(rootPath, []string{"packages/config/index.js"})
+	assert.NilError(t, err, "AffectedWorkspaces")
+	assert.DeepEqual(t, got, []string{"config", "ui", "web"})
+}
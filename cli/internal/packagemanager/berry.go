@@ -2,7 +2,7 @@ package packagemanager
 
 import (
 	"fmt"
-	"os/exec"
+	"strings"
 
 	"github.com/Masterminds/semver"
 	"github.com/vercel/turborepo/cli/internal/fs"
@@ -16,23 +16,75 @@ var nodejsBerry = PackageManager{
 	Specfile:   "package.json",
 	Lockfile:   "yarn.lock",
 	PackageDir: "node_modules",
+	DocsURL:    "https://yarnpkg.com/features/workspaces",
 
-	getWorkspaceGlobs: func(rootpath fs.AbsolutePath) ([]string, error) {
-		pkg, err := fs.ReadPackageJSON(rootpath.Join("package.json").ToStringDuringMigration())
+	MinSupportedVersion: "2.0.0",
+
+	// --immutable fails the install when yarn.lock would be modified.
+	lockfileCheckArgs: []string{"install", "--immutable"},
+
+	ciInstallArgs: []string{"install", "--immutable"},
+
+	// Berry always writes the lockfile it resolves, so the closest it comes is
+	// --immutable, which fails instead of modifying it.
+	installNoSaveArgs: []string{"install", "--immutable"},
+
+	addArgs:    []string{"add"},
+	addDevFlag: "-D",
+
+	removeArgs: []string{"remove"},
+
+	pinnedBinary: readYarnPath,
+
+	whyArgs: []string{"why"},
+
+	parseLockfile: parseBerryLockfile,
+
+	lockfileSignature: func(head []byte) bool {
+		return strings.HasPrefix(firstContentLine(head), "__metadata:")
+	},
+
+	lockfileVersion: berryLockfileVersion,
+
+	lockfileMinimumVersions: map[string]string{
+		"4": "2.0.0",
+		"5": "3.0.0",
+		"6": "3.1.0",
+		"8": "4.0.0",
+	},
+
+	lockfileFormats: []versionedLockfileFormat{
+		{since: "2.0.0", format: LockfileFormatBerry},
+	},
+
+	nodeLinker:     PnP,
+	readNodeLinker: readYarnrcNodeLinker,
+
+	readHoistConfig: readYarnrcHoistConfig,
+
+	hasWorkspaces: hasPackageJSONWorkspaces,
+
+	readsWorkspaceField: true,
+
+	getWorkspaceGlobs: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
+		workspaces, err := readPackageJSONWorkspaces(pm, rootpath)
 		if err != nil {
 			return nil, fmt.Errorf("package.json: %w", err)
 		}
-		if len(pkg.Workspaces) == 0 {
-			return nil, fmt.Errorf("package.json: no workspaces found. Turborepo requires Yarn workspaces to be defined in the root package.json")
+		if len(workspaces) == 0 {
+			return nil, fmt.Errorf("package.json: no workspaces found. Turborepo requires Yarn workspaces to be defined in the root package.json. See %v", pm.DocsURL)
 		}
-		return pkg.Workspaces, nil
+		return normalizeBerryWorkspaceGlobs(workspaces), nil
 	},
 
 	getWorkspaceIgnores: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
 		// Matches upstream values:
 		// Key code: https://github.com/yarnpkg/berry/blob/8e0c4b897b0881878a1f901230ea49b7c8113fbe/packages/yarnpkg-core/sources/Workspace.ts#L64-L70
+		// `**/.git` only excludes the contents of git directories. A git submodule has a `.git` file
+		// rather than a directory at its root, so its package.json is still discovered.
+		// `**/.yarn` covers the package cache, .yarn/cache.
 		return []string{
-			"**/node_modules",
+			"**/" + pm.PackageDir,
 			"**/.git",
 			"**/.yarn",
 		}, nil
@@ -57,29 +109,20 @@ var nodejsBerry = PackageManager{
 		return c.Check(v), nil
 	},
 
-	// Detect for berry needs to identify which version of yarn is running on the system.
+	// Detect for berry needs to identify which version of yarn is in use from files in the
+	// project directory, without running yarn, which may not be installed yet.
 	// Further, berry can be configured in an incompatible way, so we check for compatibility here as well.
 	detect: func(projectDirectory fs.AbsolutePath, packageManager *PackageManager) (bool, error) {
 		specfileExists := projectDirectory.Join(packageManager.Specfile).FileExists()
-		lockfileExists := projectDirectory.Join(packageManager.Lockfile).FileExists()
+		lockfileExists := packageManager.LockfilePath(projectDirectory).FileExists()
 
 		// Short-circuit, definitely not Yarn.
 		if !specfileExists || !lockfileExists {
 			return false, nil
 		}
 
-		cmd := exec.Command("yarn", "--version")
-		cmd.Dir = projectDirectory.ToString()
-		out, err := cmd.Output()
-		if err != nil {
-			return false, fmt.Errorf("could not detect yarn version: %w", err)
-		}
-
-		// See if we're a match when we compare these two things.
-		matches, _ := packageManager.Matches(packageManager.Slug, string(out))
-
-		// Short-circuit, definitely not Berry because version number says we're Yarn.
-		if !matches {
+		// Without any signal of berry, the project is classic.
+		if detectYarnVariant(projectDirectory) != yarnVariantBerry {
 			return false, nil
 		}
 
@@ -100,3 +143,21 @@ var nodejsBerry = PackageManager{
 		return true, nil
 	},
 }
+
+// normalizeBerryWorkspaceGlobs rewrites workspace globs the way berry interprets them.
+//
+// Berry hands the globs to globby with onlyDirectories set, and micromatch then matches
+// `packages/*/` and `packages/*` against the same directories. A leading `./` is likewise
+// dropped, since globby resolves patterns against the project cwd.
+// Key code: https://github.com/yarnpkg/berry/blob/8e0c4b897b0881878a1f901230ea49b7c8113fbe/packages/yarnpkg-core/sources/Workspace.ts#L64-L78
+func normalizeBerryWorkspaceGlobs(globs []string) []string {
+	normalized := make([]string, len(globs))
+	for i, glob := range globs {
+		glob = strings.TrimPrefix(glob, "./")
+		if trimmed := strings.TrimRight(glob, "/"); trimmed != "" {
+			glob = trimmed
+		}
+		normalized[i] = glob
+	}
+	return normalized
+}
@@ -0,0 +1,37 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_normalizeBerryWorkspaceGlobs(t *testing.T) {
+	got := normalizeBerryWorkspaceGlobs([]string{"packages/*/", "./apps/*", "tooling//", "libs/**"})
+	assert.DeepEqual(t, got, []string{"packages/*", "apps/*", "tooling", "libs/**"})
+}
+
+func Test_BerryWorkspaces_TrailingSlash(t *testing.T) {
+	// The expected set is what `yarn workspaces list` reports for this layout
+	// under yarn 3, minus the root workspace.
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                         `{"name": "root", "workspaces": ["packages/*/", "./apps/*", "tooling/cli/"]}`,
+		"yarn.lock":                            "__metadata:\n  version: 6\n",
+		"packages/ui/package.json":             `{"name": "ui"}`,
+		"packages/config/package.json":         `{"name": "config"}`,
+		"packages/README.md":                   "not a workspace",
+		"packages/empty/.gitkeep":              "",
+		"apps/web/package.json":                `{"name": "web"}`,
+		"apps/web/node_modules/x/package.json": `{"name": "x"}`,
+		"tooling/cli/package.json":             `{"name": "cli"}`,
+	})
+
+	workspaces, err := nodejsBerry.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+		"apps/web/package.json",
+		"packages/config/package.json",
+		"packages/ui/package.json",
+		"tooling/cli/package.json",
+	})
+}
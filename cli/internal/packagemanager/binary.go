@@ -0,0 +1,149 @@
+package packagemanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// ResolveBinary returns the argv prefix which runs the exact Package Manager
+// the repository at rootpath pins. In order of precedence this is:
+//
+//  1. A release checked into the repository, such as berry's `yarnPath`,
+//     run with node. It is an error for the pinned release to be missing.
+//  2. The Package Manager's command, if it is on the PATH. Corepack shims
+//     on the PATH honor the packageManager field themselves.
+//  3. The command run through Corepack, if the packageManager field pins
+//     this Package Manager and corepack is on the PATH.
+func (pm PackageManager) ResolveBinary(rootpath fs.AbsolutePath) ([]string, error) {
+	if pm.pinnedBinary != nil {
+		binary, err := pm.pinnedBinary(rootpath)
+		if err != nil {
+			return nil, err
+		}
+		if binary != "" {
+			return []string{"node", binary}, nil
+		}
+	}
+
+	if _, err := lookPath(pm.Command); err == nil {
+		return []string{pm.Command}, nil
+	}
+	if pm.pinnedVersion(rootpath.ToStringDuringMigration()) != "" {
+		if _, err := lookPath("corepack"); err == nil {
+			return []string{"corepack", pm.Command}, nil
+		}
+	}
+	return nil, pm.CheckAvailable()
+}
+
+// IsCorepackEnabled reports whether Corepack is managing this Package
+// Manager for projectDirectory, in which case invoking the command may
+// download the pinned release. The heuristics, in order, are:
+//
+//  1. COREPACK_ROOT is set. Corepack sets it while running a shim, so it is
+//     inherited by anything the Package Manager runs, including turbo.
+//  2. The command on the PATH, after resolving symlinks, lies within a
+//     `corepack` directory, as the shims installed by `corepack enable` do.
+//  3. The command is not on the PATH, but projectDirectory pins this Package
+//     Manager and corepack is on the PATH, so ResolveBinary runs it through
+//     Corepack.
+func (pm PackageManager) IsCorepackEnabled(projectDirectory string) (bool, error) {
+	if os.Getenv("COREPACK_ROOT") != "" {
+		return true, nil
+	}
+
+	binary, err := lookPath(pm.Command)
+	if err == nil {
+		if resolved, err := filepath.EvalSymlinks(binary); err == nil {
+			binary = resolved
+		}
+		for _, segment := range strings.Split(filepath.ToSlash(binary), "/") {
+			if segment == "corepack" {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	if !errors.Is(err, exec.ErrNotFound) {
+		return false, fmt.Errorf("%v: %w", pm.Command, err)
+	}
+
+	if pm.pinnedVersion(projectDirectory) == "" {
+		return false, nil
+	}
+	_, err = lookPath("corepack")
+	return err == nil, nil
+}
+
+// RunCommandResolved returns the command which runs the package.json script
+// named script with the binary chosen by ResolveBinary.
+func (pm PackageManager) RunCommandResolved(rootpath fs.AbsolutePath, script string) ([]string, error) {
+	binary, err := pm.ResolveBinary(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	return append(binary, "run", script), nil
+}
+
+// GlobalBinDir returns the directory the Package Manager links globally
+// installed binaries into, as printed by `yarn global bin`, `pnpm bin -g`, or
+// `bun pm bin -g` run in projectDirectory. For npm it is derived from
+// `npm prefix -g`, since `npm bin` was removed in npm 9. Berry removed
+// global installs, so it is an error for berry, as it is for the command to be
+// missing from the PATH.
+func (pm PackageManager) GlobalBinDir(projectDirectory string) (string, error) {
+	if pm.globalBinArgs == nil {
+		return "", fmt.Errorf("finding the global binary directory is not supported for %v", pm.Name)
+	}
+	binary, err := lookPath(pm.Command)
+	if err != nil {
+		return "", fmt.Errorf("%v is not available: %w", pm.Command, err)
+	}
+
+	cmd := exec.Command(binary, pm.globalBinArgs...)
+	cmd.Dir = projectDirectory
+	cmd.Env = append(os.Environ(), offlineEnv...)
+	out, err := runCommand(cmd)
+	command := strings.Join(append([]string{pm.Command}, pm.globalBinArgs...), " ")
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", command, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if dir == "" {
+		return "", fmt.Errorf("%v printed no directory", command)
+	}
+	if pm.globalBinIsPrefix && runtime.GOOS != "windows" {
+		dir = filepath.Join(dir, "bin")
+	}
+	return dir, nil
+}
+
+// readYarnPath returns the absolute path of the release pinned by the
+// yarnPath setting of .yarnrc.yml, or "" if none is pinned.
+func readYarnPath(rootpath fs.AbsolutePath) (string, error) {
+	contents, err := rootpath.Join(".yarnrc.yml").ReadFile()
+	if err != nil {
+		return "", nil
+	}
+	yarnRC := &util.YarnRC{}
+	if err := yaml.Unmarshal(normalizeLineEndings(contents), yarnRC); err != nil {
+		return "", fmt.Errorf(".yarnrc.yml: %w", err)
+	}
+	if yarnRC.YarnPath == "" {
+		return "", nil
+	}
+	yarnPath := fs.ResolveUnknownPath(rootpath, filepath.FromSlash(yarnRC.YarnPath))
+	if !yarnPath.FileExists() {
+		return "", fmt.Errorf(".yarnrc.yml: yarnPath %v does not exist. Restore it or run `yarn set version` to pin a release", yarnRC.YarnPath)
+	}
+	return yarnPath.ToStringDuringMigration(), nil
+}
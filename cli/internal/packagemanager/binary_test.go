@@ -0,0 +1,194 @@
+package packagemanager
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRunCommandResolved_BerryPinned(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"yarn": "1.22.19"})
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                  `{"name": "root"}`,
+		".yarnrc.yml":                   "yarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+		".yarn/releases/yarn-3.2.1.cjs": "",
+	})
+
+	got, err := nodejsBerry.RunCommandResolved(rootPath, "build")
+	assert.NilError(t, err, "RunCommandResolved")
+	assert.DeepEqual(t, got, []string{"node", rootPath.Join(".yarn", "releases", "yarn-3.2.1.cjs").ToStringDuringMigration(), "run", "build"})
+}
+
+func TestRunCommandResolved_BerryPinnedMissing(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"yarn": "1.22.19"})
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root"}`,
+		".yarnrc.yml":  "yarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+	})
+
+	_, err := nodejsBerry.RunCommandResolved(rootPath, "build")
+	assert.ErrorContains(t, err, "yarnPath .yarn/releases/yarn-3.2.1.cjs does not exist")
+}
+
+func TestRunCommandResolved_Path(t *testing.T) {
+	tests := []struct {
+		name        string
+		available   map[string]string
+		packageJSON string
+		want        []string
+		wantErr     string
+	}{
+		{
+			name:        "on the PATH",
+			available:   map[string]string{"pnpm": "8.6.0", "corepack": "0.17.0"},
+			packageJSON: `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+			want:        []string{"pnpm", "run", "build"},
+		},
+		{
+			name:        "through corepack",
+			available:   map[string]string{"corepack": "0.17.0"},
+			packageJSON: `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+			want:        []string{"corepack", "pnpm", "run", "build"},
+		},
+		{
+			name:        "corepack without a pin",
+			available:   map[string]string{"corepack": "0.17.0"},
+			packageJSON: `{"name": "root"}`,
+			wantErr:     "pnpm is not available",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeVersionCommands(t, tt.available)
+			rootPath := setupFixture(t, map[string]string{"package.json": tt.packageJSON})
+
+			got, err := nodejsPnpm.RunCommandResolved(rootPath, "build")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err, "RunCommandResolved")
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestIsCorepackEnabled(t *testing.T) {
+	tests := []struct {
+		name        string
+		corepackEnv string
+		binaries    map[string]string
+		packageJSON string
+		want        bool
+	}{
+		{
+			name:        "COREPACK_ROOT set",
+			corepackEnv: "/usr/local/lib/node_modules/corepack",
+			binaries:    map[string]string{"pnpm": "/usr/local/bin/pnpm"},
+			packageJSON: `{"name": "root"}`,
+			want:        true,
+		},
+		{
+			name:        "corepack shim on the PATH",
+			binaries:    map[string]string{"pnpm": "/usr/local/lib/node_modules/corepack/shims/pnpm"},
+			packageJSON: `{"name": "root"}`,
+			want:        true,
+		},
+		{
+			name:        "standalone install on the PATH",
+			binaries:    map[string]string{"pnpm": "/usr/local/bin/pnpm", "corepack": "/usr/local/bin/corepack"},
+			packageJSON: `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+			want:        false,
+		},
+		{
+			name:        "pinned and run through corepack",
+			binaries:    map[string]string{"corepack": "/usr/local/bin/corepack"},
+			packageJSON: `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+			want:        true,
+		},
+		{
+			name:        "not pinned",
+			binaries:    map[string]string{"corepack": "/usr/local/bin/corepack"},
+			packageJSON: `{"name": "root"}`,
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{"package.json": tt.packageJSON})
+			t.Setenv("COREPACK_ROOT", tt.corepackEnv)
+			originalLookPath := lookPath
+			t.Cleanup(func() { lookPath = originalLookPath })
+			lookPath = func(file string) (string, error) {
+				if binary, ok := tt.binaries[file]; ok {
+					return binary, nil
+				}
+				return "", exec.ErrNotFound
+			}
+
+			got, err := nodejsPnpm.IsCorepackEnabled(rootPath.ToStringDuringMigration())
+			assert.NilError(t, err, "IsCorepackEnabled")
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestGlobalBinDir(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"npm": "", "yarn": "", "pnpm": "", "bun": ""})
+	var ran []string
+	runCommand = func(cmd *exec.Cmd) ([]byte, error) {
+		ran = cmd.Args[1:]
+		if ran[0] == "prefix" {
+			return []byte("/usr/local\n"), nil
+		}
+		return []byte("/home/user/.local/bin\n"), nil
+	}
+
+	want := map[string][]string{
+		"nodejs-npm":  {"prefix", "-g"},
+		"nodejs-yarn": {"global", "bin"},
+		"nodejs-pnpm": {"bin", "-g"},
+		"nodejs-bun":  {"pm", "bin", "-g"},
+	}
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			ran = nil
+			dir, err := packageManager.GlobalBinDir(t.TempDir())
+			if packageManager.Name == "nodejs-berry" {
+				assert.ErrorContains(t, err, "finding the global binary directory is not supported for nodejs-berry")
+				assert.Assert(t, ran == nil)
+				return
+			}
+			assert.NilError(t, err, "GlobalBinDir")
+			switch {
+			case packageManager.Name == "nodejs-npm" && runtime.GOOS == "windows":
+				assert.Equal(t, dir, "/usr/local")
+			case packageManager.Name == "nodejs-npm":
+				// npm prints the prefix, which holds bin/.
+				assert.Equal(t, dir, filepath.Join("/usr/local", "bin"))
+			default:
+				assert.Equal(t, dir, "/home/user/.local/bin")
+			}
+			assert.DeepEqual(t, ran, want[packageManager.Name])
+		})
+	}
+}
+
+func TestGlobalBinDir_NotInstalled(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{})
+	_, err := nodejsPnpm.GlobalBinDir(t.TempDir())
+	assert.ErrorContains(t, err, "pnpm is not available")
+}
+
+func TestGlobalBinDir_CommandFails(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"npm": ""})
+	runCommand = func(cmd *exec.Cmd) ([]byte, error) {
+		return nil, errors.New("exit status 1")
+	}
+	_, err := nodejsNpm.GlobalBinDir(t.TempDir())
+	assert.ErrorContains(t, err, "npm prefix -g: exit status 1")
+}
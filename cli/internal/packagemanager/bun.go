@@ -0,0 +1,99 @@
+package packagemanager
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Masterminds/semver"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+var nodejsBun = PackageManager{
+	Name:       "nodejs-bun",
+	Slug:       "bun",
+	Command:    "bun",
+	Specfile:   "package.json",
+	Lockfile:   bunBinaryLockfile,
+	PackageDir: "node_modules",
+	DocsURL:    "https://bun.sh/docs/install/workspaces",
+
+	// bun 1 stabilized workspaces.
+	MinSupportedVersion: "1.0.0",
+
+	// --frozen-lockfile fails the install when bun.lockb would be modified.
+	lockfileCheckArgs: []string{"install", "--frozen-lockfile"},
+
+	ciInstallArgs: []string{"install", "--frozen-lockfile"},
+
+	installNoSaveArgs: []string{"install", "--no-save"},
+
+	globalBinArgs: []string{"pm", "bin", "-g"},
+
+	addArgs:    []string{"add"},
+	addDevFlag: "-d",
+
+	removeArgs: []string{"remove"},
+
+	// bun has no equivalent of `why`, so the closest is listing the whole dependency tree.
+	whyArgs:     []string{"pm", "ls", "--all"},
+	whyListsAll: true,
+
+	// The binary lockfile starts with a shebang so that running it prints its contents.
+	lockfileSignature: func(head []byte) bool {
+		return bytes.HasPrefix(head, []byte("#!/usr/bin/env bun"))
+	},
+
+	readLockfile: func(rootpath fs.AbsolutePath) (Lockfile, error) {
+		return ParseBunLockfile(rootpath)
+	},
+
+	lockfileFormats: []versionedLockfileFormat{
+		{since: "0.0.0", format: LockfileFormatBunBinary},
+		{since: "1.2.0", format: LockfileFormatBunText},
+	},
+
+	nodeLinker: Hoisted,
+
+	hasWorkspaces: hasPackageJSONWorkspaces,
+
+	readsWorkspaceField: true,
+
+	getWorkspaceGlobs: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
+		workspaces, err := readPackageJSONWorkspaces(pm, rootpath)
+		if err != nil {
+			return nil, fmt.Errorf("package.json: %w", err)
+		}
+		if len(workspaces) == 0 {
+			return nil, fmt.Errorf("package.json: no workspaces found. Turborepo requires bun workspaces to be defined in the root package.json. See %v", pm.DocsURL)
+		}
+		return workspaces, nil
+	},
+
+	getWorkspaceIgnores: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
+		return []string{
+			"**/" + pm.PackageDir + "/**",
+		}, nil
+	},
+
+	// Canary builds report a prerelease version, e.g. 1.0.7-canary.20240101, which is
+	// still bun. An empty version, as from a bare TURBO_PACKAGE_MANAGER=bun, also matches.
+	Matches: func(manager string, version string) (bool, error) {
+		if manager != "bun" {
+			return false, nil
+		}
+		if version == "" {
+			return true, nil
+		}
+		if _, err := semver.NewVersion(version); err != nil {
+			return false, fmt.Errorf("could not parse bun version: %w", err)
+		}
+		return true, nil
+	},
+
+	detect: func(projectDirectory fs.AbsolutePath, packageManager *PackageManager) (bool, error) {
+		specfileExists := projectDirectory.Join(packageManager.Specfile).FileExists()
+		lockfileExists := packageManager.LockfilePath(projectDirectory).FileExists() || projectDirectory.Join(bunTextLockfile).FileExists()
+
+		return (specfileExists && lockfileExists), nil
+	},
+}
@@ -0,0 +1,60 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_BunMatches(t *testing.T) {
+	tests := []struct {
+		manager string
+		version string
+		want    bool
+		wantErr bool
+	}{
+		{manager: "bun", version: "1.0.7", want: true},
+		{manager: "bun", version: "1.0.7-canary.20240101", want: true},
+		{manager: "bun", version: "1.1.0-canary.1+a1b2c3d", want: true},
+		{manager: "bun", version: "", want: true},
+		{manager: "bun", version: "canary", wantErr: true},
+		{manager: "npm", version: "1.0.7", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.manager+"@"+tt.version, func(t *testing.T) {
+			got, err := nodejsBun.Matches(tt.manager, tt.version)
+			if tt.wantErr {
+				assert.Assert(t, err != nil, "expected an error")
+				return
+			}
+			assert.NilError(t, err, "Matches")
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func Test_BunVersion(t *testing.T) {
+	for _, output := range []string{"1.0.7", "1.0.7-canary.20240101"} {
+		t.Run(output, func(t *testing.T) {
+			fakeVersionCommands(t, map[string]string{"bun": output})
+
+			version, err := nodejsBun.GetVersion(t.TempDir())
+			assert.NilError(t, err, "GetVersion")
+			assert.Equal(t, version, output)
+
+			matches, err := nodejsBun.Matches(nodejsBun.Slug, version)
+			assert.NilError(t, err, "Matches")
+			assert.Assert(t, matches, "expected %v to match bun", version)
+		})
+	}
+}
+
+func Test_BunDetectsTextLockfile(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root", "workspaces": ["packages/*"]}`,
+		"bun.lock":     `{"lockfileVersion": 0}`,
+	})
+	got, err := detectPackageManager(rootPath)
+	assert.NilError(t, err, "detectPackageManager")
+	assert.Equal(t, got.Name, "nodejs-bun")
+}
@@ -0,0 +1,171 @@
+package packagemanager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Masterminds/semver"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/xxhash"
+)
+
+// packageManagerCache is the on-disk record of a previously resolved package
+// manager, stored at .turbo/package-manager.json.
+type packageManagerCache struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// The detectionInputsHash of the project directory when the cache was written.
+	Inputs string `json:"inputs"`
+}
+
+// detectionMarkers are the files whose presence, rather than contents,
+// detection depends on, in addition to each package manager's lockfile. The
+// yarn entries are the signals read by detectYarnVariant.
+var detectionMarkers = []string{"package.json", "pnpm-workspace.yaml", ".yarnrc.yml", ".yarnrc", ".pnp.cjs", ".yarn/releases", bunTextLockfile}
+
+// detectionMarkerPaths returns the paths of the detectionMarkers and of each
+// package manager's lockfile, as located by LockfilePath, in projectDirectory.
+func detectionMarkerPaths(projectDirectory fs.AbsolutePath) []fs.AbsolutePath {
+	paths := make([]fs.AbsolutePath, 0, len(detectionMarkers)+len(packageManagers))
+	for _, marker := range detectionMarkers {
+		paths = append(paths, projectDirectory.Join(marker))
+	}
+	for _, packageManager := range packageManagers {
+		paths = append(paths, packageManager.LockfilePath(projectDirectory))
+	}
+	return paths
+}
+
+// detectionInputsHash returns a hash of everything in projectDirectory that
+// determines which package manager is detected: the packageManager
+// declaration, wherever it is made, the yarnPath of .yarnrc.yml, the mise
+// configuration, and which lockfiles and other marker files are present.
+func detectionInputsHash(projectDirectory fs.AbsolutePath) (string, error) {
+	hash := xxhash.New()
+	pkg, err := ReadRootManifest(projectDirectory)
+	if errors.Is(err, ErrInvalidRootManifest) {
+		return "", err
+	}
+	declaration, file, err := readPackageManagerDeclaration(projectDirectory, pkg)
+	if err != nil {
+		return "", err
+	}
+	if declaration != nil {
+		if _, err := fmt.Fprintf(hash, "%v\x00%v\x00%v\n", file, declaration.PackageManager, declaration.PackageManagers); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := fmt.Fprintf(hash, "yarnPath\x00%v\n", yarnPathSetting(projectDirectory)); err != nil {
+		return "", err
+	}
+	for _, file := range miseConfigFiles {
+		contents, err := projectDirectory.Join(file).ReadFile()
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		if _, err := fmt.Fprintf(hash, "%v\x00%q\n", file, contents); err != nil {
+			return "", err
+		}
+	}
+
+	for _, marker := range detectionMarkerPaths(projectDirectory) {
+		_, err := marker.Lstat()
+		if _, err := fmt.Fprintf(hash, "%v\x00%v\n", marker, err == nil); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func packageManagerCachePath(projectDirectory fs.AbsolutePath) fs.AbsolutePath {
+	return projectDirectory.Join(".turbo", "package-manager.json")
+}
+
+// WritePackageManagerCache records the resolved package manager and its
+// version, along with a hash of the inputs which determined them, so that
+// subsequent invocations can skip detection while those inputs are unchanged.
+func WritePackageManagerCache(projectDirectory fs.AbsolutePath, packageManager *PackageManager, version string) error {
+	inputs, err := detectionInputsHash(projectDirectory)
+	if err != nil {
+		return err
+	}
+	jsonBytes, err := json.Marshal(&packageManagerCache{
+		Name:    packageManager.Slug,
+		Version: version,
+		Inputs:  inputs,
+	})
+	if err != nil {
+		return err
+	}
+	path := packageManagerCachePath(projectDirectory)
+	if err := path.EnsureDir(); err != nil {
+		return err
+	}
+	return path.WriteFile(jsonBytes, 0644)
+}
+
+// InvalidatePackageManagerCache removes the cache written by
+// WritePackageManagerCache, so that the next invocation performs detection.
+// It is not an error for there to be no cache.
+func InvalidatePackageManagerCache(projectDirectory fs.AbsolutePath) error {
+	err := packageManagerCachePath(projectDirectory).Remove()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// readPackageManagerCache returns the package manager recorded by
+// WritePackageManagerCache, or nil if the cache is absent, invalid, older
+// than any of the files detection depends on, or was written for different
+// detection inputs.
+func readPackageManagerCache(projectDirectory fs.AbsolutePath) *PackageManager {
+	cache := readPackageManagerCacheFile(projectDirectory)
+	if cache == nil {
+		return nil
+	}
+	return findPackageManager(cache.Name, cache.Version)
+}
+
+// readPackageManagerCacheFile returns the contents of a fresh and well-formed
+// cache file, or nil.
+func readPackageManagerCacheFile(projectDirectory fs.AbsolutePath) *packageManagerCache {
+	path := packageManagerCachePath(projectDirectory)
+	info, err := path.Lstat()
+	if err != nil {
+		return nil
+	}
+
+	inputs := detectionMarkerPaths(projectDirectory)
+	inputs = append(inputs, projectDirectory.Join(packageManagerYAML))
+	for _, file := range miseConfigFiles {
+		inputs = append(inputs, projectDirectory.Join(file))
+	}
+	for _, input := range inputs {
+		inputInfo, err := input.Lstat()
+		if err == nil && inputInfo.ModTime().After(info.ModTime()) {
+			return nil
+		}
+	}
+
+	b, err := path.ReadFile()
+	if err != nil {
+		return nil
+	}
+	var cache packageManagerCache
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return nil
+	}
+	if _, err := semver.NewVersion(cache.Version); err != nil {
+		return nil
+	}
+	if inputs, err := detectionInputsHash(projectDirectory); err != nil || inputs != cache.Inputs {
+		return nil
+	}
+	return &cache
+}
@@ -0,0 +1,152 @@
+package packagemanager
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func TestPackageManagerCache(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":   `{"name": "root"}`,
+		"pnpm-lock.yaml": "lockfileVersion: 5.4\n",
+	})
+
+	err := WritePackageManagerCache(rootPath, &nodejsBerry, "3.2.1")
+	assert.NilError(t, err, "WritePackageManagerCache")
+
+	got, err := GetPackageManager(rootPath, nil)
+	assert.NilError(t, err, "GetPackageManager")
+	assert.Equal(t, got.Name, "nodejs-berry")
+
+	// Touching an input after the cache was written makes it stale.
+	future := time.Now().Add(time.Hour)
+	assert.NilError(t, os.Chtimes(rootPath.Join("pnpm-lock.yaml").ToStringDuringMigration(), future, future), "Chtimes")
+	assert.Assert(t, readPackageManagerCache(rootPath) == nil)
+}
+
+func TestPackageManagerCache_Persist(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"pnpm": "7.14.0"})
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":   `{"name": "root"}`,
+		"pnpm-lock.yaml": "lockfileVersion: 5.4\n",
+	})
+	opts := Opts{PersistCache: true}
+
+	got, reason, err := resolvePackageManager(rootPath, nil, opts)
+	assert.NilError(t, err, "resolvePackageManager")
+	assert.Equal(t, got.Name, "nodejs-pnpm")
+	assert.Equal(t, reason, ReasonDetected)
+
+	// Unchanged inputs skip detection.
+	got, reason, err = resolvePackageManager(rootPath, nil, opts)
+	assert.NilError(t, err, "resolvePackageManager")
+	assert.Equal(t, got.Name, "nodejs-pnpm")
+	assert.Equal(t, reason, ReasonCache)
+
+	// A new lockfile changes the inputs, even one older than the cache.
+	past := time.Now().Add(-time.Hour)
+	yarnLock := rootPath.Join("yarn.lock")
+	assert.NilError(t, yarnLock.WriteFile([]byte("# yarn lockfile v1\n"), 0644), "WriteFile")
+	assert.NilError(t, os.Chtimes(yarnLock.ToStringDuringMigration(), past, past), "Chtimes")
+	assert.Assert(t, readPackageManagerCache(rootPath) == nil)
+	assert.NilError(t, yarnLock.Remove(), "Remove")
+	assert.Assert(t, readPackageManagerCache(rootPath) != nil)
+
+	assert.NilError(t, InvalidatePackageManagerCache(rootPath), "InvalidatePackageManagerCache")
+	assert.Assert(t, readPackageManagerCache(rootPath) == nil)
+	assert.NilError(t, InvalidatePackageManagerCache(rootPath), "InvalidatePackageManagerCache without a cache")
+}
+
+func TestPackageManagerCache_NotPersisted(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"pnpm": "7.14.0"})
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":   `{"name": "root"}`,
+		"pnpm-lock.yaml": "lockfileVersion: 5.4\n",
+	})
+
+	_, _, err := resolvePackageManager(rootPath, nil, Opts{})
+	assert.NilError(t, err, "resolvePackageManager")
+	assert.Assert(t, !packageManagerCachePath(rootPath).FileExists(), "cache written without PersistCache")
+
+	t.Setenv(packageManagerEnvVar, "pnpm")
+	_, reason, err := resolvePackageManager(rootPath, nil, Opts{PersistCache: true})
+	assert.NilError(t, err, "resolvePackageManager")
+	assert.Equal(t, reason, ReasonEnvironment)
+	assert.Assert(t, !packageManagerCachePath(rootPath).FileExists(), "cache written for the environment")
+}
+
+func TestPackageManagerCache_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{name: "malformed json", contents: `{"name":`},
+		{name: "unknown manager", contents: `{"name":"pip","version":"1.2.3"}`},
+		{name: "invalid version", contents: `{"name":"pnpm","version":"latest"}`},
+		{name: "no inputs hash", contents: `{"name":"pnpm","version":"7.14.0"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{
+				".turbo/package-manager.json": tt.contents,
+			})
+			assert.Assert(t, readPackageManagerCache(rootPath) == nil)
+		})
+	}
+}
+
+func TestPackageManagerCache_DetectionInputs(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, rootPath fs.AbsolutePath)
+	}{
+		{name: ".pnp.cjs", change: func(t *testing.T, rootPath fs.AbsolutePath) {
+			writeFileInPast(t, rootPath.Join(".pnp.cjs"), "")
+		}},
+		{name: ".yarn/releases", change: func(t *testing.T, rootPath fs.AbsolutePath) {
+			writeFileInPast(t, rootPath.Join(".yarn", "releases", "yarn-3.2.1.cjs"), "")
+		}},
+		{name: ".yarnrc", change: func(t *testing.T, rootPath fs.AbsolutePath) {
+			writeFileInPast(t, rootPath.Join(".yarnrc"), "")
+		}},
+		{name: "yarnPath", change: func(t *testing.T, rootPath fs.AbsolutePath) {
+			writeFileInPast(t, rootPath.Join(".yarnrc.yml"), "yarnPath: .yarn/releases/yarn-3.2.1.cjs\n")
+		}},
+		{name: "mise.toml", change: func(t *testing.T, rootPath fs.AbsolutePath) {
+			writeFileInPast(t, rootPath.Join("mise.toml"), "[tools]\npnpm = \"8.6.0\"\n")
+		}},
+		{name: "lockfile override", change: func(t *testing.T, rootPath fs.AbsolutePath) {
+			writeFileInPast(t, rootPath.Join("locks", "pnpm-lock.yaml"), "lockfileVersion: 5.4\n")
+			t.Setenv("TURBO_LOCKFILE_PNPM", "locks/pnpm-lock.yaml")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{
+				"package.json": `{"name": "root"}`,
+				"yarn.lock":    "# yarn lockfile v1\n",
+				".yarnrc.yml":  "nodeLinker: node-modules\n",
+			})
+			assert.NilError(t, WritePackageManagerCache(rootPath, &nodejsYarn, "1.22.19"), "WritePackageManagerCache")
+			assert.Assert(t, readPackageManagerCache(rootPath) != nil)
+
+			tt.change(t, rootPath)
+			assert.Assert(t, readPackageManagerCache(rootPath) == nil)
+		})
+	}
+}
+
+// writeFileInPast writes contents to path, backdating it so that only the
+// detection inputs hash, rather than its modification time, can make a cache
+// stale.
+func writeFileInPast(t *testing.T, path fs.AbsolutePath, contents string) {
+	t.Helper()
+	assert.NilError(t, path.EnsureDir(), "EnsureDir")
+	assert.NilError(t, path.WriteFile([]byte(contents), 0644), "WriteFile")
+	past := time.Now().Add(-time.Hour)
+	assert.NilError(t, os.Chtimes(path.ToStringDuringMigration(), past, past), "Chtimes")
+}
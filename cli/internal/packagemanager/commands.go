@@ -0,0 +1,74 @@
+package packagemanager
+
+// LockfileCheckCommand returns the command which verifies that the lockfile is
+// up to date with the workspace manifests. Where the Package Manager has no
+// check-only mode, this is the closest frozen-install command, which fails
+// rather than modifying the lockfile.
+func (pm PackageManager) LockfileCheckCommand() []string {
+	return append([]string{pm.Command}, pm.lockfileCheckArgs...)
+}
+
+// CIInstallCommand returns the canonical install command for CI, which
+// installs exactly what the lockfile specifies and fails if it is out of date.
+func (pm PackageManager) CIInstallCommand() []string {
+	return append([]string{pm.Command}, pm.ciInstallArgs...)
+}
+
+// InstallNoSaveCommand returns the command which installs dependencies
+// without modifying the lockfile, for scripted setups which must leave it
+// untouched. Where the Package Manager cannot install without writing the
+// lockfile, as with berry, this is the closest frozen-install command, which
+// fails rather than modifying it.
+func (pm PackageManager) InstallNoSaveCommand() []string {
+	return append([]string{pm.Command}, pm.installNoSaveArgs...)
+}
+
+// AddCommand returns the command which adds pkgName as a dependency, or as a
+// devDependency if dev is set.
+func (pm PackageManager) AddCommand(pkgName string, dev bool) []string {
+	command := append([]string{pm.Command}, pm.addArgs...)
+	if dev {
+		command = append(command, pm.addDevFlag)
+	}
+	return append(command, pkgName)
+}
+
+// RemoveCommand returns the command which removes the dependency pkgName.
+func (pm PackageManager) RemoveCommand(pkgName string) []string {
+	command := append([]string{pm.Command}, pm.removeArgs...)
+	return append(command, pkgName)
+}
+
+// RunCommand returns the command which runs the package.json script named script.
+func (pm PackageManager) RunCommand(script string) []string {
+	return []string{pm.Command, "run", script}
+}
+
+// DryRunCommand is a command for previewing what turbo would invoke.
+type DryRunCommand struct {
+	// The command, which includes any dry-run flags the Package Manager has.
+	Argv []string
+
+	// Whether the Package Manager cannot dry-run the command, so that Argv
+	// must be printed rather than executed.
+	PrintOnly bool
+}
+
+// DryRunRunCommand returns the command for previewing RunCommand(script).
+// None of npm, yarn, berry, pnpm, or bun can dry-run a script: npm ignores
+// --dry-run for `run`, and the others have no such flag. So for every
+// Package Manager the command is returned unchanged and marked PrintOnly.
+func (pm PackageManager) DryRunRunCommand(script string) DryRunCommand {
+	return DryRunCommand{Argv: pm.RunCommand(script), PrintOnly: true}
+}
+
+// WhyCommand returns the command which explains why pkgName is installed. Where
+// the Package Manager has no such command, as with bun, this is the closest
+// equivalent, which lists the entire dependency tree.
+func (pm PackageManager) WhyCommand(pkgName string) []string {
+	command := append([]string{pm.Command}, pm.whyArgs...)
+	if pm.whyListsAll {
+		return command
+	}
+	return append(command, pkgName)
+}
@@ -0,0 +1,176 @@
+package packagemanager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+func TestLockfileCheckCommand(t *testing.T) {
+	want := map[string][]string{
+		"nodejs-npm":   {"npm", "ci", "--dry-run"},
+		"nodejs-berry": {"yarn", "install", "--immutable"},
+		"nodejs-yarn":  {"yarn", "install", "--frozen-lockfile"},
+		"nodejs-pnpm":  {"pnpm", "install", "--frozen-lockfile", "--prefer-offline"},
+		"nodejs-bun":   {"bun", "install", "--frozen-lockfile"},
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			got := packageManager.LockfileCheckCommand()
+			if !reflect.DeepEqual(got, want[packageManager.Name]) {
+				t.Errorf("LockfileCheckCommand() = %v, want %v", got, want[packageManager.Name])
+			}
+		})
+	}
+}
+
+func TestCIInstallCommand(t *testing.T) {
+	want := map[string][]string{
+		"nodejs-npm":   {"npm", "ci"},
+		"nodejs-berry": {"yarn", "install", "--immutable"},
+		"nodejs-yarn":  {"yarn", "install", "--frozen-lockfile"},
+		"nodejs-pnpm":  {"pnpm", "install", "--frozen-lockfile"},
+		"nodejs-bun":   {"bun", "install", "--frozen-lockfile"},
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			got := packageManager.CIInstallCommand()
+			if !reflect.DeepEqual(got, want[packageManager.Name]) {
+				t.Errorf("CIInstallCommand() = %v, want %v", got, want[packageManager.Name])
+			}
+		})
+	}
+}
+
+func TestCIInstallCommand_YarnVariants(t *testing.T) {
+	tests := []struct {
+		packageManager string
+		want           []string
+	}{
+		{packageManager: "yarn@1.22.19", want: []string{"yarn", "install", "--frozen-lockfile"}},
+		{packageManager: "yarn@3.2.1", want: []string{"yarn", "install", "--immutable"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.packageManager, func(t *testing.T) {
+			resolved, err := readPackageManager(&fs.PackageJSON{PackageManager: tt.packageManager})
+			if err != nil {
+				t.Fatalf("readPackageManager() error = %v", err)
+			}
+			if got := resolved.CIInstallCommand(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CIInstallCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallNoSaveCommand(t *testing.T) {
+	want := map[string][]string{
+		"nodejs-npm":   {"npm", "install", "--no-save"},
+		"nodejs-berry": {"yarn", "install", "--immutable"},
+		"nodejs-yarn":  {"yarn", "install", "--pure-lockfile"},
+		"nodejs-pnpm":  {"pnpm", "install", "--no-lockfile"},
+		"nodejs-bun":   {"bun", "install", "--no-save"},
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			got := packageManager.InstallNoSaveCommand()
+			if !reflect.DeepEqual(got, want[packageManager.Name]) {
+				t.Errorf("InstallNoSaveCommand() = %v, want %v", got, want[packageManager.Name])
+			}
+		})
+	}
+}
+
+func TestAddCommand(t *testing.T) {
+	want := map[string][][]string{
+		"nodejs-npm":   {{"npm", "install", "react"}, {"npm", "install", "-D", "react"}},
+		"nodejs-berry": {{"yarn", "add", "react"}, {"yarn", "add", "-D", "react"}},
+		"nodejs-yarn":  {{"yarn", "add", "react"}, {"yarn", "add", "-D", "react"}},
+		"nodejs-pnpm":  {{"pnpm", "add", "react"}, {"pnpm", "add", "-D", "react"}},
+		"nodejs-bun":   {{"bun", "add", "react"}, {"bun", "add", "-d", "react"}},
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			for i, dev := range []bool{false, true} {
+				got := packageManager.AddCommand("react", dev)
+				if !reflect.DeepEqual(got, want[packageManager.Name][i]) {
+					t.Errorf("AddCommand(react, %v) = %v, want %v", dev, got, want[packageManager.Name][i])
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveCommand(t *testing.T) {
+	want := map[string][]string{
+		"nodejs-npm":   {"npm", "uninstall", "react"},
+		"nodejs-berry": {"yarn", "remove", "react"},
+		"nodejs-yarn":  {"yarn", "remove", "react"},
+		"nodejs-pnpm":  {"pnpm", "remove", "react"},
+		"nodejs-bun":   {"bun", "remove", "react"},
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			got := packageManager.RemoveCommand("react")
+			if !reflect.DeepEqual(got, want[packageManager.Name]) {
+				t.Errorf("RemoveCommand(react) = %v, want %v", got, want[packageManager.Name])
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			got := packageManager.RunCommand("build")
+			want := []string{packageManager.Command, "run", "build"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("RunCommand(build) = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDryRunRunCommand(t *testing.T) {
+	want := map[string]DryRunCommand{
+		"nodejs-npm":   {Argv: []string{"npm", "run", "build"}, PrintOnly: true},
+		"nodejs-berry": {Argv: []string{"yarn", "run", "build"}, PrintOnly: true},
+		"nodejs-yarn":  {Argv: []string{"yarn", "run", "build"}, PrintOnly: true},
+		"nodejs-pnpm":  {Argv: []string{"pnpm", "run", "build"}, PrintOnly: true},
+		"nodejs-bun":   {Argv: []string{"bun", "run", "build"}, PrintOnly: true},
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			got := packageManager.DryRunRunCommand("build")
+			if !reflect.DeepEqual(got, want[packageManager.Name]) {
+				t.Errorf("DryRunRunCommand(build) = %v, want %v", got, want[packageManager.Name])
+			}
+		})
+	}
+}
+
+func TestWhyCommand(t *testing.T) {
+	want := map[string][]string{
+		"nodejs-npm":   {"npm", "why", "react"},
+		"nodejs-berry": {"yarn", "why", "react"},
+		"nodejs-yarn":  {"yarn", "why", "react"},
+		"nodejs-pnpm":  {"pnpm", "why", "react"},
+		"nodejs-bun":   {"bun", "pm", "ls", "--all"},
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			got := packageManager.WhyCommand("react")
+			if !reflect.DeepEqual(got, want[packageManager.Name]) {
+				t.Errorf("WhyCommand(react) = %v, want %v", got, want[packageManager.Name])
+			}
+		})
+	}
+}
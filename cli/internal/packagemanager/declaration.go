@@ -0,0 +1,93 @@
+package packagemanager
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// packageManagerYAML is a sibling of package.json which may declare the
+// package manager for tools that do not edit package.json.
+const packageManagerYAML = ".package-manager.yaml"
+
+// packageManagerSource is a file which may declare the package manager with a
+// packageManager field.
+type packageManagerSource struct {
+	// The file read by this source, relative to the project directory.
+	file string
+
+	// read returns the declaration, with only PackageManager and
+	// PackageManagers set, or nil if the source does not declare one.
+	read func(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON) (*fs.PackageJSON, error)
+}
+
+// packageManagerSources are consulted in order of precedence. The first
+// source to declare a package manager is used, even if the declaration is
+// invalid, so that .package-manager.yaml is only a fallback for a
+// package.json without the packageManager field.
+var packageManagerSources = []packageManagerSource{
+	{
+		file: "package.json",
+		read: func(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON) (*fs.PackageJSON, error) {
+			if pkg == nil || (pkg.PackageManager == "" && len(pkg.PackageManagers) == 0) {
+				return nil, nil
+			}
+			return pkg, nil
+		},
+	},
+	{
+		file: packageManagerYAML,
+		read: readPackageManagerYAML,
+	},
+}
+
+// readPackageManagerYAML reads the packageManager key of .package-manager.yaml.
+func readPackageManagerYAML(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON) (*fs.PackageJSON, error) {
+	yamlPath := projectDirectory.Join(packageManagerYAML)
+	if !yamlPath.FileExists() {
+		return nil, nil
+	}
+	contents, err := yamlPath.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+	var declaration struct {
+		PackageManager string `yaml:"packageManager"`
+	}
+	if err := yaml.Unmarshal(contents, &declaration); err != nil {
+		return nil, err
+	}
+	if declaration.PackageManager == "" {
+		return nil, nil
+	}
+	return &fs.PackageJSON{PackageManager: declaration.PackageManager}, nil
+}
+
+// readPackageManagerDeclaration returns the declaration of the first of
+// packageManagerSources to declare the package manager, along with the file
+// it was read from. pkg is the root package.json, or nil if there is none.
+// If no source declares a package manager, the declaration is nil.
+func readPackageManagerDeclaration(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON) (*fs.PackageJSON, string, error) {
+	for _, source := range packageManagerSources {
+		declaration, err := source.read(projectDirectory, pkg)
+		if err != nil {
+			return nil, source.file, err
+		}
+		if declaration != nil {
+			return declaration, source.file, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// readDeclaredPackageManager returns the package manager declared by the
+// first of packageManagerSources to declare one, along with the file it was
+// read from. If no source declares a package manager, all results are zero.
+func readDeclaredPackageManager(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON) (*PackageManager, string, error) {
+	declaration, file, err := readPackageManagerDeclaration(projectDirectory, pkg)
+	if err != nil || declaration == nil {
+		return nil, file, err
+	}
+	packageManager, err := readPackageManager(declaration)
+	return packageManager, file, err
+}
@@ -0,0 +1,49 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetPackageManager_PackageManagerYAML(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":          `{"name": "root"}`,
+		".package-manager.yaml": "# Read by our release tooling.\npackageManager: pnpm@8.6.0\n",
+	})
+
+	got, reason, err := resolvePackageManager(rootPath, nil, Opts{})
+	assert.NilError(t, err, "resolvePackageManager")
+	assert.Equal(t, got.Name, "nodejs-pnpm")
+	assert.Equal(t, reason, ReasonPackageManagerField)
+
+	spawns := fakeVersionCommands(t, map[string]string{"pnpm": "7.9.0"})
+	version, source, err := got.ResolveVersion(rootPath.ToStringDuringMigration())
+	assert.NilError(t, err, "ResolveVersion")
+	assert.Equal(t, version, "8.6.0")
+	assert.Equal(t, source, VersionSourcePackageManagerField)
+	assert.Equal(t, *spawns, 0)
+}
+
+func TestGetPackageManager_PackageManagerYAMLPrecedence(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":          `{"name": "root", "packageManager": "npm@8.19.2"}`,
+		".package-manager.yaml": "packageManager: pnpm@8.6.0\n",
+	})
+	pkg := &fs.PackageJSON{Name: "root", PackageManager: "npm@8.19.2"}
+
+	got, err := GetPackageManager(rootPath, pkg)
+	assert.NilError(t, err, "GetPackageManager")
+	assert.Equal(t, got.Name, "nodejs-npm")
+}
+
+func TestGetPackageManager_InvalidPackageManagerYAML(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":          `{"name": "root"}`,
+		".package-manager.yaml": "packageManager: [pnpm\n",
+	})
+
+	_, err := GetPackageManager(rootPath, nil)
+	assert.ErrorContains(t, err, `.package-manager.yaml: invalid "packageManager" field: `)
+}
@@ -0,0 +1,41 @@
+package packagemanager
+
+import (
+	"fmt"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// DependencySet is the dependencies declared by a single manifest, keyed by
+// dependency name with the declared version specifier as the value. Each
+// section is non-nil, even if the manifest omits it.
+type DependencySet struct {
+	Dependencies         map[string]string
+	DevDependencies      map[string]string
+	PeerDependencies     map[string]string
+	OptionalDependencies map[string]string
+}
+
+// GetWorkspaceDependencies returns the dependencies declared by the manifest
+// at manifestPath. Only the manifest is read; versions are not resolved
+// against the lockfile.
+func GetWorkspaceDependencies(manifestPath string) (*DependencySet, error) {
+	manifest, err := fs.ReadPackageJSON(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", manifestPath, err)
+	}
+	return &DependencySet{
+		Dependencies:         copyDependencies(manifest.Dependencies),
+		DevDependencies:      copyDependencies(manifest.DevDependencies),
+		PeerDependencies:     copyDependencies(manifest.PeerDependencies),
+		OptionalDependencies: copyDependencies(manifest.OptionalDependencies),
+	}, nil
+}
+
+func copyDependencies(dependencies map[string]string) map[string]string {
+	copied := make(map[string]string, len(dependencies))
+	for name, specifier := range dependencies {
+		copied[name] = specifier
+	}
+	return copied
+}
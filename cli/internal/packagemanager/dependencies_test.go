@@ -0,0 +1,41 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetWorkspaceDependencies(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"packages/ui/package.json": `{
+  "name": "ui",
+  "dependencies": {"clsx": "^1.2.1", "tsconfig": "workspace:*"},
+  "devDependencies": {"typescript": "^4.9.0"},
+  "peerDependencies": {"react": ">=17"},
+  "optionalDependencies": {"fsevents": "^2.3.2"}
+}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	got, err := GetWorkspaceDependencies(rootPath.Join("packages", "ui", "package.json").ToStringDuringMigration())
+	assert.NilError(t, err, "GetWorkspaceDependencies")
+	assert.DeepEqual(t, got, &DependencySet{
+		Dependencies:         map[string]string{"clsx": "^1.2.1", "tsconfig": "workspace:*"},
+		DevDependencies:      map[string]string{"typescript": "^4.9.0"},
+		PeerDependencies:     map[string]string{"react": ">=17"},
+		OptionalDependencies: map[string]string{"fsevents": "^2.3.2"},
+	})
+
+	got, err = GetWorkspaceDependencies(rootPath.Join("packages", "config", "package.json").ToStringDuringMigration())
+	assert.NilError(t, err, "GetWorkspaceDependencies")
+	assert.DeepEqual(t, got, &DependencySet{
+		Dependencies:         map[string]string{},
+		DevDependencies:      map[string]string{},
+		PeerDependencies:     map[string]string{},
+		OptionalDependencies: map[string]string{},
+	})
+
+	_, err = GetWorkspaceDependencies(rootPath.Join("missing", "package.json").ToStringDuringMigration())
+	assert.ErrorContains(t, err, "parsing ")
+}
@@ -0,0 +1,86 @@
+package packagemanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// InternalDependencyClosure returns the names of every workspace reachable from
+// workspaceName through internal dependencies, as found by
+// BuildWorkspaceGraph, sorted by name. The workspace itself is not included.
+// It is an error for the dependencies to contain a cycle.
+func (pm PackageManager) InternalDependencyClosure(rootpath fs.AbsolutePath, workspaceName string) ([]string, error) {
+	graph, err := pm.BuildWorkspaceGraph(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := graph.Workspaces[workspaceName]; !ok {
+		return nil, fmt.Errorf("workspace %v not found", workspaceName)
+	}
+
+	c := &dependencyClosure{
+		graph:   graph,
+		visited: make(map[string]bool),
+		onPath:  make(map[string]bool),
+	}
+	if err := c.visit(workspaceName); err != nil {
+		return nil, err
+	}
+
+	closure := make([]string, 0, len(c.visited))
+	for name := range c.visited {
+		if name != workspaceName {
+			closure = append(closure, name)
+		}
+	}
+	sort.Strings(closure)
+	return closure, nil
+}
+
+// dependencyClosure is the state of a depth-first walk of internal dependencies.
+type dependencyClosure struct {
+	// The internal dependencies being walked.
+	graph *WorkspaceGraph
+
+	// Workspaces which have been fully walked.
+	visited map[string]bool
+
+	// Workspaces on the current path from the starting workspace, in order.
+	path   []string
+	onPath map[string]bool
+}
+
+func (c *dependencyClosure) visit(name string) error {
+	if c.onPath[name] {
+		cycle := append([]string{}, c.path[indexOf(c.path, name):]...)
+		cycle = append(cycle, name)
+		return fmt.Errorf("dependency cycle detected: %v", strings.Join(cycle, " -> "))
+	}
+	if c.visited[name] {
+		return nil
+	}
+
+	c.path = append(c.path, name)
+	c.onPath[name] = true
+	for _, dependency := range c.graph.Dependencies(name) {
+		if err := c.visit(dependency); err != nil {
+			return err
+		}
+	}
+	c.path = c.path[:len(c.path)-1]
+	delete(c.onPath, name)
+	c.visited[name] = true
+	return nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// diamondNpmLockfile describes web -> (ui, utils), ui -> config, utils -> config.
+const diamondNpmLockfile = `{
+  "name": "root",
+  "lockfileVersion": 2,
+  "packages": {
+    "": {"name": "root", "workspaces": ["apps/*", "packages/*"]},
+    "apps/web": {"name": "web", "dependencies": {"ui": "*", "utils": "*", "react": "^18.0.0"}},
+    "packages/ui": {"name": "ui", "dependencies": {"config": "*"}},
+    "packages/utils": {"name": "utils", "devDependencies": {"config": "*"}},
+    "packages/config": {"name": "config"},
+    "node_modules/ui": {"resolved": "packages/ui", "link": true},
+    "node_modules/utils": {"resolved": "packages/utils", "link": true},
+    "node_modules/config": {"resolved": "packages/config", "link": true},
+    "node_modules/react": {"version": "18.2.0"}
+  }
+}`
+
+func TestInternalDependencyClosure(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"package-lock.json":            diamondNpmLockfile,
+		"apps/web/package.json":        `{"name": "web"}`,
+		"packages/ui/package.json":     `{"name": "ui"}`,
+		"packages/utils/package.json":  `{"name": "utils"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	closure, err := nodejsNpm.InternalDependencyClosure(rootPath, "web")
+	assert.NilError(t, err, "InternalDependencyClosure")
+	assert.DeepEqual(t, closure, []string{"config", "ui", "utils"})
+
+	closure, err = nodejsNpm.InternalDependencyClosure(rootPath, "config")
+	assert.NilError(t, err, "InternalDependencyClosure")
+	assert.DeepEqual(t, closure, []string{})
+
+	_, err = nodejsNpm.InternalDependencyClosure(rootPath, "missing")
+	assert.ErrorContains(t, err, "workspace missing not found")
+}
+
+func TestInternalDependencyClosure_Cycle(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root", "workspaces": ["packages/*"]}`,
+		"package-lock.json": `{
+  "lockfileVersion": 2,
+  "packages": {
+    "": {"name": "root"},
+    "packages/a": {"name": "a", "dependencies": {"b": "*"}},
+    "packages/b": {"name": "b", "dependencies": {"c": "*"}},
+    "packages/c": {"name": "c", "dependencies": {"b": "*"}},
+    "node_modules/a": {"resolved": "packages/a", "link": true},
+    "node_modules/b": {"resolved": "packages/b", "link": true},
+    "node_modules/c": {"resolved": "packages/c", "link": true}
+  }
+}`,
+		"packages/a/package.json": `{"name": "a"}`,
+		"packages/b/package.json": `{"name": "b"}`,
+		"packages/c/package.json": `{"name": "c"}`,
+	})
+
+	_, err := nodejsNpm.InternalDependencyClosure(rootPath, "a")
+	assert.ErrorContains(t, err, "dependency cycle detected: b -> c -> b")
+}
+
+func TestInternalDependencyClosure_YarnClassic(t *testing.T) {
+	// yarn.lock cannot be read, so internal dependencies come from manifests.
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"yarn.lock":                    "# yarn lockfile v1\n",
+		"apps/web/package.json":        `{"name": "web", "dependencies": {"ui": "*", "react": "^18.2.0"}}`,
+		"packages/ui/package.json":     `{"name": "ui", "devDependencies": {"config": "*"}}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	closure, err := nodejsYarn.InternalDependencyClosure(rootPath, "web")
+	assert.NilError(t, err, "InternalDependencyClosure")
+	assert.DeepEqual(t, closure, []string{"config", "ui"})
+}
@@ -0,0 +1,143 @@
+package packagemanager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// packageManagerEnvVar overrides package manager identification, formatted as
+// either `<manager>@<version>` or just `<manager>`.
+const packageManagerEnvVar = "TURBO_PACKAGE_MANAGER"
+
+// GetPackageManagerFromEnv resolves the package manager named by the
+// TURBO_PACKAGE_MANAGER environment variable, as read by env, without
+// inspecting the filesystem. It returns nil if the variable is unset.
+func GetPackageManagerFromEnv(env func(string) string) (*PackageManager, error) {
+	value := strings.TrimSpace(env(packageManagerEnvVar))
+	if value == "" {
+		return nil, nil
+	}
+
+	manager, version := value, ""
+	if strings.Contains(value, "@") {
+		var err error
+		manager, version, err = ParsePackageManagerString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", packageManagerEnvVar, err)
+		}
+	} else if manager == "yarn" {
+		// Only the version distinguishes yarn classic from berry.
+		return nil, fmt.Errorf("%v: a version is required for yarn, e.g. yarn@1.22.19 or yarn@3.2.1, received: %v", packageManagerEnvVar, value)
+	}
+
+	if packageManager := findPackageManager(manager, version); packageManager != nil {
+		return packageManager, nil
+	}
+	return nil, fmt.Errorf("%v: unsupported package manager, expected one of npm, pnpm, yarn, or bun, received: %v", packageManagerEnvVar, value)
+}
+
+// workspacesEnvVar lists the workspace directories explicitly, bypassing
+// workspace discovery, for hermetic builds which must not glob the filesystem.
+const workspacesEnvVar = "TURBO_WORKSPACES"
+
+// workspacesFromEnv returns the package.json files of the workspace
+// directories listed by TURBO_WORKSPACES, separated by newlines or commas and
+// either absolute or relative to rootpath, in the order listed. ok is false if
+// the variable is unset. It is an error for a listed directory to have no
+// package.json.
+func workspacesFromEnv(rootpath fs.AbsolutePath) (manifests []string, ok bool, err error) {
+	value := strings.TrimSpace(os.Getenv(workspacesEnvVar))
+	if value == "" {
+		return nil, false, nil
+	}
+
+	manifests = []string{}
+	seen := make(map[string]bool)
+	for _, dir := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == ',' }) {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		manifest := fs.ResolveUnknownPath(rootpath, filepath.FromSlash(dir)).Join("package.json")
+		if !manifest.FileExists() {
+			return nil, true, fmt.Errorf("%v: %v has no package.json", workspacesEnvVar, dir)
+		}
+		if !seen[manifest.ToStringDuringMigration()] {
+			seen[manifest.ToStringDuringMigration()] = true
+			manifests = append(manifests, manifest.ToStringDuringMigration())
+		}
+	}
+	return manifests, true, nil
+}
+
+// lockfileEnvVar returns the environment variable which overrides the
+// location of the Package Manager's lockfile, e.g. TURBO_LOCKFILE_PNPM.
+func (pm PackageManager) lockfileEnvVar() string {
+	return "TURBO_LOCKFILE_" + strings.ToUpper(pm.Slug)
+}
+
+// LockfilePath returns the location of the Package Manager's lockfile for the
+// project. This is Lockfile within projectDirectory unless overridden by the
+// TURBO_LOCKFILE_<SLUG> environment variable, which may be absolute or
+// relative to projectDirectory.
+func (pm PackageManager) LockfilePath(projectDirectory fs.AbsolutePath) fs.AbsolutePath {
+	if override := strings.TrimSpace(os.Getenv(pm.lockfileEnvVar())); override != "" {
+		return fs.ResolveUnknownPath(projectDirectory, override)
+	}
+	lockfilePath := projectDirectory.Join(pm.Lockfile)
+	if !foldsCase(projectDirectory, pm.Lockfile) {
+		return lockfilePath
+	}
+	// On a case-insensitive filesystem the exact name also finds a lockfile
+	// cased differently, e.g. Package-Lock.json, so report its name on disk.
+	return projectDirectory.Join(nameOnDisk(projectDirectory, pm.Lockfile))
+}
+
+// foldsCase reports whether name exists in dir and is also found by the same
+// name cased differently, which is only so on a case-insensitive filesystem.
+// Only then can the name on disk differ from name.
+func foldsCase(dir fs.AbsolutePath, name string) bool {
+	recased := strings.ToUpper(name)
+	if recased == name {
+		recased = strings.ToLower(name)
+	}
+	if recased == name {
+		return false
+	}
+	info, err := dir.Join(name).Lstat()
+	if err != nil {
+		return false
+	}
+	recasedInfo, err := dir.Join(recased).Lstat()
+	return err == nil && os.SameFile(info, recasedInfo)
+}
+
+// readDir lists a directory, overridable for tests.
+var readDir = os.ReadDir
+
+// nameOnDisk returns the name of the entry of dir which name refers to,
+// preferring an exact match over one which differs only in case. It returns
+// name if dir has no such entry or cannot be read.
+func nameOnDisk(dir fs.AbsolutePath, name string) string {
+	entries, err := readDir(dir.ToStringDuringMigration())
+	if err != nil {
+		return name
+	}
+	folded := ""
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return name
+		}
+		if folded == "" && strings.EqualFold(entry.Name(), name) {
+			folded = entry.Name()
+		}
+	}
+	if folded == "" {
+		return name
+	}
+	return folded
+}
@@ -0,0 +1,167 @@
+package packagemanager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func envFromMap(values map[string]string) func(string) string {
+	return func(key string) string {
+		return values[key]
+	}
+}
+
+func TestGetPackageManagerFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "unset", value: ""},
+		{name: "manager and version", value: "pnpm@8.6.0", want: "nodejs-pnpm"},
+		{name: "manager only", value: "npm", want: "nodejs-npm"},
+		{name: "yarn classic", value: "yarn@1.22.19", want: "nodejs-yarn"},
+		{name: "yarn berry", value: "yarn@3.2.1", want: "nodejs-berry"},
+		{name: "yarn without version", value: "yarn", wantErr: "a version is required for yarn"},
+		{name: "malformed version", value: "pnpm@8", wantErr: "TURBO_PACKAGE_MANAGER: We could not parse"},
+		{name: "unknown manager", value: "pip", wantErr: "unsupported package manager"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetPackageManagerFromEnv(envFromMap(map[string]string{"TURBO_PACKAGE_MANAGER": tt.value}))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err, "GetPackageManagerFromEnv")
+			if tt.want == "" {
+				assert.Assert(t, got == nil)
+				return
+			}
+			assert.Equal(t, got.Name, tt.want)
+		})
+	}
+}
+
+func TestGetPackageManager_EnvOverride(t *testing.T) {
+	t.Setenv("TURBO_PACKAGE_MANAGER", "pnpm@8.6.0")
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":      `{"name": "root"}`,
+		"package-lock.json": "{}",
+	})
+
+	got, err := GetPackageManager(rootPath, &fs.PackageJSON{PackageManager: "npm@9.0.0"})
+	assert.NilError(t, err, "GetPackageManager")
+	assert.Equal(t, got.Name, "nodejs-pnpm")
+}
+
+func TestLockfilePath_EnvOverride(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                  `{"name": "root"}`,
+		"ci/locks/pnpm-lock.ci.yaml":    "lockfileVersion: 5.4\n",
+		"ci/locks/package-lock.ci.json": "{}",
+	})
+
+	assert.Equal(t, nodejsPnpm.LockfilePath(rootPath), rootPath.Join("pnpm-lock.yaml"))
+	_, err := detectPackageManager(rootPath)
+	assert.ErrorContains(t, err, "We did not detect an in-use package manager")
+
+	t.Setenv("TURBO_LOCKFILE_PNPM", "ci/locks/pnpm-lock.ci.yaml")
+	assert.Equal(t, nodejsPnpm.LockfilePath(rootPath), rootPath.Join("ci", "locks", "pnpm-lock.ci.yaml"))
+	got, err := detectPackageManager(rootPath)
+	assert.NilError(t, err, "detectPackageManager")
+	assert.Equal(t, got.Name, "nodejs-pnpm")
+
+	absolute := rootPath.Join("ci", "locks", "package-lock.ci.json")
+	t.Setenv("TURBO_LOCKFILE_PNPM", "")
+	t.Setenv("TURBO_LOCKFILE_NPM", absolute.ToStringDuringMigration())
+	assert.Equal(t, nodejsNpm.LockfilePath(rootPath), absolute)
+	got, err = detectPackageManager(rootPath)
+	assert.NilError(t, err, "detectPackageManager")
+	assert.Equal(t, got.Name, "nodejs-npm")
+}
+
+func TestLockfilePath_CaseInsensitive(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":      `{"name": "root"}`,
+		"Package-Lock.json": "{}",
+	})
+	assert.Equal(t, nameOnDisk(rootPath, "package-lock.json"), "Package-Lock.json")
+	assert.Equal(t, nameOnDisk(rootPath, "yarn.lock"), "yarn.lock")
+
+	if !rootPath.Join("package-lock.json").FileExists() {
+		// A case-sensitive filesystem has no package-lock.json at all.
+		assert.Equal(t, nodejsNpm.LockfilePath(rootPath), rootPath.Join("package-lock.json"))
+		_, err := detectPackageManager(rootPath)
+		assert.ErrorContains(t, err, "We did not detect an in-use package manager")
+		return
+	}
+	assert.Equal(t, nodejsNpm.LockfilePath(rootPath), rootPath.Join("Package-Lock.json"))
+	got, err := detectPackageManager(rootPath)
+	assert.NilError(t, err, "detectPackageManager")
+	assert.Equal(t, got.Name, "nodejs-npm")
+}
+
+func TestLockfilePath_PrefersExactName(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root"}`,
+		"yarn.lock":    "",
+	})
+	// Sorts before yarn.lock, so it is seen first.
+	if rootPath.Join("Yarn.lock").FileExists() {
+		t.Skip("filesystem is case-insensitive")
+	}
+	assert.NilError(t, rootPath.Join("Yarn.lock").WriteFile([]byte(""), 0644), "WriteFile")
+	assert.Equal(t, nodejsYarn.LockfilePath(rootPath), rootPath.Join("yarn.lock"))
+}
+
+func TestGetWorkspaces_EnvOverride(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":        `{"name": "web"}`,
+		"packages/ui/package.json":     `{"name": "ui"}`,
+		"tools/internal/package.json":  `{"name": "internal"}`,
+		"packages/utils/package.json":  `{"name": "utils"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+	t.Setenv("TURBO_WORKSPACES", "packages/ui,\ntools/internal\n"+rootPath.Join("apps", "web").ToStringDuringMigration()+"\n")
+
+	want := []string{
+		rootPath.Join("packages", "ui", "package.json").ToStringDuringMigration(),
+		rootPath.Join("tools", "internal", "package.json").ToStringDuringMigration(),
+		rootPath.Join("apps", "web", "package.json").ToStringDuringMigration(),
+	}
+	workspaces, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, workspaces, want)
+
+	workspaces, err = nodejsNpm.GetWorkspacesFast(rootPath)
+	assert.NilError(t, err, "GetWorkspacesFast")
+	assert.DeepEqual(t, workspaces, want)
+
+	t.Setenv("TURBO_WORKSPACES", "packages/ui,packages/missing")
+	_, err = nodejsNpm.GetWorkspaces(rootPath)
+	assert.ErrorContains(t, err, "TURBO_WORKSPACES: packages/missing has no package.json")
+}
+
+func TestLockfilePath_CaseSensitive(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":      `{"name": "root"}`,
+		"package-lock.json": "{}",
+	})
+	if rootPath.Join("PACKAGE-LOCK.JSON").FileExists() {
+		t.Skip("filesystem is case-insensitive")
+	}
+	originalReadDir := readDir
+	t.Cleanup(func() { readDir = originalReadDir })
+	readDir = func(name string) ([]os.DirEntry, error) {
+		t.Fatalf("listed %v", name)
+		return nil, nil
+	}
+
+	assert.Equal(t, nodejsNpm.LockfilePath(rootPath), rootPath.Join("package-lock.json"))
+}
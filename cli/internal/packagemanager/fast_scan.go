@@ -0,0 +1,187 @@
+package packagemanager
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/doublestar"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// GetWorkspacesFast returns the same list of package.json files as GetWorkspaces,
+// but walks the repository exactly once and matches every package.json it
+// finds against the workspace globs in memory. Directories covered by an
+// ignore glob are pruned instead of walked, which is where most of the time
+// goes in large repositories.
+//
+// Results are the same as GetWorkspaces with default options: symlinked
+// directories are not descended into, and .turbo/workspace-include and
+// .turboignore files are honored. Re-included directories are searched
+// separately after the walk. The root package.json is never included.
+func (pm PackageManager) GetWorkspacesFast(rootpath fs.AbsolutePath) ([]string, error) {
+	if manifests, ok, err := workspacesFromEnv(rootpath); ok {
+		return manifests, err
+	}
+	globs, err := pm.workspaceGlobs(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	ignores, err := pm.getWorkspaceIgnores(pm, rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	root := rootpath.ToStringDuringMigration()
+	includes, err := compileScanPatterns(root, globs, "package.json")
+	if err != nil {
+		return nil, err
+	}
+	// Excludes operate on entire folders, same as globby.
+	excludes, err := compileScanPatterns(root, ignores, "**")
+	if err != nil {
+		return nil, err
+	}
+	maxDepth := scanDepth(includes)
+
+	var manifests []string
+	err = walkDirRoot(root, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			// Match globby, which ignores IO errors while walking.
+			if d != nil && d.IsDir() && p != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if p == root {
+				return nil
+			}
+			if maxDepth >= 0 && strings.Count(rel, "/")+1 >= maxDepth {
+				return filepath.SkipDir
+			}
+			excluded, err := matchesAny(excludes, rel)
+			if err != nil {
+				return err
+			}
+			if excluded {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Name() != "package.json" {
+			return nil
+		}
+		included, err := matchesAny(includes, rel)
+		if err != nil || !included {
+			return err
+		}
+		excluded, err := matchesAny(excludes, rel)
+		if err != nil || excluded {
+			return err
+		}
+		manifests = append(manifests, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reincludes, err := readWorkspaceIncludes(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	if len(reincludes) > 0 {
+		justJsons := make([]string, len(globs))
+		for i, glob := range globs {
+			justJsons[i] = filepath.Join(glob, "package.json")
+		}
+		manifests, err = reincludeWorkspaces(rootpath, manifests, justJsons, reincludes, GlobbyMatcher)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifests, err = applyTurboIgnores(rootpath, manifests)
+	if err != nil {
+		return nil, err
+	}
+	return withoutRootManifest(rootpath, manifests), nil
+}
+
+// walkDirRoot is filepath.WalkDir, except that root is followed if it is a
+// symlink, such as a repository root reached through a linked worktree.
+// Paths passed to fn remain below root as given.
+func walkDirRoot(root string, fn iofs.WalkDirFunc) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil || realRoot == root {
+		return filepath.WalkDir(root, fn)
+	}
+	return filepath.WalkDir(realRoot, func(p string, d iofs.DirEntry, err error) error {
+		rel, relErr := filepath.Rel(realRoot, p)
+		if relErr != nil {
+			return relErr
+		}
+		if rel == "." {
+			return fn(root, d, err)
+		}
+		return fn(filepath.Join(root, rel), d, err)
+	})
+}
+
+// compileScanPatterns converts globs into slash-separated patterns relative to
+// root, each with suffix appended, rejecting any that escape root.
+func compileScanPatterns(root string, globs []string, suffix string) ([]string, error) {
+	patterns := make([]string, len(globs))
+	for i, glob := range globs {
+		joined := filepath.Join(root, glob, suffix)
+		rel, err := filepath.Rel(root, joined)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("the path you are attempting to specify (%s) is outside of the root", joined)
+		}
+		patterns[i] = filepath.ToSlash(rel)
+	}
+	return patterns, nil
+}
+
+// scanDepth returns the number of path segments the deepest include pattern
+// can match, or -1 if any pattern can match at arbitrary depth.
+func scanDepth(includes []string) int {
+	depth := 0
+	for _, include := range includes {
+		if strings.Contains(include, "**") || strings.Contains(include, "{") {
+			return -1
+		}
+		segments := len(strings.Split(path.Clean(include), "/"))
+		if segments > depth {
+			depth = segments
+		}
+	}
+	return depth
+}
+
+func matchesAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := doublestar.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,107 @@
+package packagemanager
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func exampleRoots(t testing.TB) map[string]fs.AbsolutePath {
+	repoRoot, err := fs.GetCwd()
+	assert.NilError(t, err, "GetCwd")
+	return map[string]fs.AbsolutePath{
+		"nodejs-npm":   repoRoot.Join("../../../examples/basic"),
+		"nodejs-berry": repoRoot.Join("../../../examples/basic"),
+		"nodejs-yarn":  repoRoot.Join("../../../examples/basic"),
+		"nodejs-pnpm":  repoRoot.Join("../../../examples/with-pnpm"),
+		"nodejs-bun":   repoRoot.Join("../../../examples/basic"),
+	}
+}
+
+func Test_GetWorkspacesFast(t *testing.T) {
+	rootPath := exampleRoots(t)
+	for _, packageManager := range packageManagers {
+		pm := packageManager
+		t.Run(pm.Name, func(t *testing.T) {
+			want, err := pm.GetWorkspaces(rootPath[pm.Name])
+			assert.NilError(t, err, "GetWorkspaces")
+			got, err := pm.GetWorkspacesFast(rootPath[pm.Name])
+			assert.NilError(t, err, "GetWorkspacesFast")
+
+			sort.Strings(want)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetWorkspacesFast() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func BenchmarkGetWorkspaces(b *testing.B) {
+	rootPath := exampleRoots(b)
+	for _, pm := range packageManagers {
+		b.Run(pm.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := pm.GetWorkspaces(rootPath[pm.Name]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetWorkspacesFast(b *testing.B) {
+	rootPath := exampleRoots(b)
+	for _, pm := range packageManagers {
+		b.Run(pm.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := pm.GetWorkspacesFast(rootPath[pm.Name]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func Test_GetWorkspacesFast_Fixture(t *testing.T) {
+	files := map[string]string{
+		"package.json":        `{"name": "root", "workspaces": ["apps/*", "packages/**"]}`,
+		"pnpm-workspace.yaml": "packages:\n  - apps/*\n  - packages/**\n",
+		// Re-included from within node_modules, which is otherwise ignored.
+		".turbo/workspace-include":                               "packages/vendor/node_modules/*\n",
+		"apps/web/package.json":                                  `{"name": "web"}`,
+		"apps/docs/package.json":                                 `{"name": "docs"}`,
+		"apps/web/node_modules/dep/package.json":                 `{"name": "dep"}`,
+		"packages/ui/package.json":                               `{"name": "ui"}`,
+		"packages/ui/nested/package.json":                        `{"name": "nested"}`,
+		"packages/legacy/old/package.json":                       `{"name": "old"}`,
+		"packages/vendor/node_modules/patched/package.json":      `{"name": "patched"}`,
+		"packages/vendor/node_modules/other/nested/package.json": `{"name": "other"}`,
+		"packages/.turboignore":                                  "ui/nested\n",
+		"pool/linked/package.json":                               `{"name": "linked"}`,
+	}
+	for _, pm := range []PackageManager{nodejsNpm, nodejsPnpm} {
+		pm := pm
+		t.Run(pm.Name, func(t *testing.T) {
+			rootPath := setupFixture(t, files)
+			assert.NilError(t, rootPath.Join("apps", "linked").Symlink(rootPath.Join("pool", "linked").ToStringDuringMigration()), "Symlink")
+
+			want, err := pm.GetWorkspaces(rootPath)
+			assert.NilError(t, err, "GetWorkspaces")
+			got, err := pm.GetWorkspacesFast(rootPath)
+			assert.NilError(t, err, "GetWorkspacesFast")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, got), relativeWorkspaces(t, rootPath, want))
+			assert.Assert(t, reflect.DeepEqual(relativeWorkspaces(t, rootPath, want), []string{
+				"apps/docs/package.json",
+				"apps/web/package.json",
+				"packages/legacy/old/package.json",
+				"packages/ui/package.json",
+				"packages/vendor/node_modules/other/nested/package.json",
+				"packages/vendor/node_modules/patched/package.json",
+			}))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package packagemanager
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// FileLink is a dependency declared with a `file:` specifier whose target is
+// another workspace, making it an internal dependency in the same way as one
+// declared with the `workspace:` protocol.
+type FileLink struct {
+	// The name of the workspace declaring the dependency.
+	Workspace string
+
+	// The manifest section declaring the dependency, e.g. "devDependencies".
+	Section string
+
+	// The name the dependency is declared under.
+	Dependency string
+
+	// The version specifier the dependency is declared with, e.g. `file:../ui`.
+	Specifier string
+
+	// The name of the workspace the specifier points to. This is usually,
+	// but not necessarily, the same as Dependency.
+	Target string
+}
+
+// ResolveFileLinks returns each dependency whose `file:` specifier points to
+// the directory of a discovered workspace. Specifiers pointing anywhere else,
+// such as a tarball or a directory outside the workspaces, are not internal
+// and are omitted. Links are sorted by workspace directory, then section,
+// then dependency name.
+func (pm PackageManager) ResolveFileLinks(rootpath fs.AbsolutePath) ([]FileLink, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	byDir := make(map[string]string, len(workspaces))
+	for _, workspace := range workspaces {
+		byDir[workspace.Dir] = workspace.Name
+	}
+
+	links := []FileLink{}
+	for _, workspace := range workspaces {
+		sections := []struct {
+			name         string
+			dependencies map[string]string
+		}{
+			{"dependencies", workspace.Manifest.Dependencies},
+			{"devDependencies", workspace.Manifest.DevDependencies},
+			{"optionalDependencies", workspace.Manifest.OptionalDependencies},
+			{"peerDependencies", workspace.Manifest.PeerDependencies},
+		}
+		for _, section := range sections {
+			var sectionLinks []FileLink
+			for name, specifier := range section.dependencies {
+				dir, ok := fileLinkDir(rootpath, workspace.Dir, specifier)
+				if !ok {
+					continue
+				}
+				if target, ok := byDir[dir]; ok {
+					sectionLinks = append(sectionLinks, FileLink{
+						Workspace:  workspace.Name,
+						Section:    section.name,
+						Dependency: name,
+						Specifier:  specifier,
+						Target:     target,
+					})
+				}
+			}
+			sort.Slice(sectionLinks, func(i, j int) bool {
+				return sectionLinks[i].Dependency < sectionLinks[j].Dependency
+			})
+			links = append(links, sectionLinks...)
+		}
+	}
+	return links, nil
+}
+
+// fileLinkDir returns the slash-separated directory, relative to rootpath,
+// which a `file:` specifier declared by the workspace at workspaceDir points
+// to. ok is false for other specifiers.
+func fileLinkDir(rootpath fs.AbsolutePath, workspaceDir string, specifier string) (dir string, ok bool) {
+	if !strings.HasPrefix(specifier, "file:") {
+		return "", false
+	}
+	target := strings.TrimPrefix(specifier, "file:")
+	if filepath.IsAbs(target) {
+		relative, err := filepath.Rel(rootpath.ToStringDuringMigration(), target)
+		if err != nil {
+			return "", false
+		}
+		return path.Clean(filepath.ToSlash(relative)), true
+	}
+	return path.Join(workspaceDir, filepath.ToSlash(target)), true
+}
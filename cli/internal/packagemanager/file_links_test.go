@@ -0,0 +1,29 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestResolveFileLinks(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json": `{
+  "name": "web",
+  "dependencies": {"ui": "file:../../packages/ui", "react": "^18.2.0", "vendored": "file:../../vendor/vendored"},
+  "devDependencies": {"my-config": "file:../../packages/config/", "archive": "file:../../vendor/archive.tgz"}
+}`,
+		"packages/ui/package.json":     `{"name": "ui", "dependencies": {"config": "file:../config"}}`,
+		"packages/config/package.json": `{"name": "config"}`,
+		"vendor/vendored/package.json": `{"name": "vendored"}`,
+	})
+
+	links, err := nodejsNpm.ResolveFileLinks(rootPath)
+	assert.NilError(t, err, "ResolveFileLinks")
+	assert.DeepEqual(t, links, []FileLink{
+		{Workspace: "web", Section: "dependencies", Dependency: "ui", Specifier: "file:../../packages/ui", Target: "ui"},
+		{Workspace: "web", Section: "devDependencies", Dependency: "my-config", Specifier: "file:../../packages/config/", Target: "config"},
+		{Workspace: "ui", Section: "dependencies", Dependency: "config", Specifier: "file:../config", Target: "config"},
+	})
+}
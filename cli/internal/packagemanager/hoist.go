@@ -0,0 +1,152 @@
+package packagemanager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gopkg.in/yaml.v3"
+)
+
+// HoistConfig describes how far a Package Manager hoists dependencies within
+// node_modules, which determines where a package can resolve its imports from.
+type HoistConfig struct {
+	// Whether dependencies are hoisted at all. For pnpm this is the `hoist`
+	// setting, which hoists into the virtual store's node_modules.
+	Hoist bool
+
+	// The pnpm `hoist-pattern` globs of dependencies hoisted into the virtual
+	// store's node_modules, or nil for other Package Managers.
+	HoistPatterns []string
+
+	// The pnpm `public-hoist-pattern` globs of dependencies hoisted into the
+	// root node_modules, or nil for other Package Managers.
+	PublicHoistPatterns []string
+
+	// The berry `nmHoistingLimits` setting: "none", "workspaces", or
+	// "dependencies". "" for other Package Managers.
+	HoistingLimits string
+
+	// The berry `nmMode` setting: "classic", "hardlinks-local", or
+	// "hardlinks-global". "" for other Package Managers.
+	NodeModulesMode string
+}
+
+// GetHoistingConfig returns the effective hoisting settings of the repository
+// at rootpath for pm, from .npmrc for pnpm and from .yarnrc.yml for berry,
+// with any unset value defaulted as the Package Manager does. npm, yarn
+// classic, and bun cannot be configured, and always hoist as far as possible.
+func GetHoistingConfig(rootpath fs.AbsolutePath, pm *PackageManager) (*HoistConfig, error) {
+	if pm.readHoistConfig == nil {
+		return &HoistConfig{Hoist: true}, nil
+	}
+	return pm.readHoistConfig(rootpath)
+}
+
+// readNpmrcHoistConfig reads the pnpm hoisting settings from .npmrc. Patterns
+// may be given either as a comma-separated value or as repeated `key[]=`
+// entries. `shamefully-hoist=true` is the same as a public-hoist-pattern of
+// `*`.
+func readNpmrcHoistConfig(rootpath fs.AbsolutePath) (*HoistConfig, error) {
+	entries, err := readNpmrcEntries(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &HoistConfig{
+		Hoist:         true,
+		HoistPatterns: []string{"*"},
+		// The default before pnpm 10, which hoists nothing publicly.
+		PublicHoistPatterns: []string{"*eslint*", "*prettier*"},
+	}
+	shamefullyHoist := false
+	// A pattern array replaces the default the first time it is seen, and is
+	// appended to by each later `key[]=` entry.
+	replaced := make(map[string]bool)
+	patterns := func(key string, value string, current []string) []string {
+		values := strings.Split(unquoteNpmrcValue(value), ",")
+		if strings.HasSuffix(key, "[]") && replaced[key] {
+			current = append(current, values...)
+		} else {
+			current = values
+		}
+		replaced[key] = true
+		var trimmed []string
+		for _, pattern := range current {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				trimmed = append(trimmed, pattern)
+			}
+		}
+		return trimmed
+	}
+	for _, entry := range entries {
+		switch entry.key {
+		case "hoist":
+			hoist, err := strconv.ParseBool(unquoteNpmrcValue(entry.value))
+			if err != nil {
+				return nil, fmt.Errorf(".npmrc: invalid hoist %q", entry.value)
+			}
+			config.Hoist = hoist
+		case "shamefully-hoist":
+			shamefullyHoist, err = strconv.ParseBool(unquoteNpmrcValue(entry.value))
+			if err != nil {
+				return nil, fmt.Errorf(".npmrc: invalid shamefully-hoist %q", entry.value)
+			}
+		case "hoist-pattern", "hoist-pattern[]":
+			config.HoistPatterns = patterns(entry.key, entry.value, config.HoistPatterns)
+		case "public-hoist-pattern", "public-hoist-pattern[]":
+			config.PublicHoistPatterns = patterns(entry.key, entry.value, config.PublicHoistPatterns)
+		}
+	}
+	if shamefullyHoist {
+		config.PublicHoistPatterns = []string{"*"}
+	}
+	if !config.Hoist {
+		config.HoistPatterns = nil
+	}
+	return config, nil
+}
+
+// yarnrcHoisting is the hoisting configuration of a berry .yarnrc.yml.
+type yarnrcHoisting struct {
+	NmHoistingLimits string `yaml:"nmHoistingLimits"`
+	NmMode           string `yaml:"nmMode"`
+}
+
+// readYarnrcHoistConfig reads the berry hoisting settings from .yarnrc.yml.
+func readYarnrcHoistConfig(rootpath fs.AbsolutePath) (*HoistConfig, error) {
+	config := &HoistConfig{
+		Hoist:           true,
+		HoistingLimits:  "none",
+		NodeModulesMode: "classic",
+	}
+	yarnrcPath := rootpath.Join(".yarnrc.yml")
+	if !yarnrcPath.FileExists() {
+		return config, nil
+	}
+	contents, err := yarnrcPath.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf(".yarnrc.yml: %w", err)
+	}
+	var yarnrc yarnrcHoisting
+	if err := yaml.Unmarshal(normalizeLineEndings(contents), &yarnrc); err != nil {
+		return nil, fmt.Errorf(".yarnrc.yml: %w", err)
+	}
+
+	switch yarnrc.NmHoistingLimits {
+	case "":
+	case "none", "workspaces", "dependencies":
+		config.HoistingLimits = yarnrc.NmHoistingLimits
+	default:
+		return nil, fmt.Errorf(".yarnrc.yml: unknown nmHoistingLimits %q", yarnrc.NmHoistingLimits)
+	}
+	switch yarnrc.NmMode {
+	case "":
+	case "classic", "hardlinks-local", "hardlinks-global":
+		config.NodeModulesMode = yarnrc.NmMode
+	default:
+		return nil, fmt.Errorf(".yarnrc.yml: unknown nmMode %q", yarnrc.NmMode)
+	}
+	return config, nil
+}
@@ -0,0 +1,102 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetHoistingConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		pm    *PackageManager
+		files map[string]string
+		want  *HoistConfig
+	}{
+		{
+			name: "npm always hoists",
+			pm:   &nodejsNpm,
+			files: map[string]string{
+				".npmrc": "hoist=false\n",
+			},
+			want: &HoistConfig{Hoist: true},
+		},
+		{
+			name: "pnpm defaults",
+			pm:   &nodejsPnpm,
+			want: &HoistConfig{
+				Hoist:               true,
+				HoistPatterns:       []string{"*"},
+				PublicHoistPatterns: []string{"*eslint*", "*prettier*"},
+			},
+		},
+		{
+			name: "pnpm pattern arrays",
+			pm:   &nodejsPnpm,
+			files: map[string]string{
+				".npmrc": "hoist-pattern[]=*types*\r\nhoist-pattern[]=@babel/*\r\npublic-hoist-pattern=\"*react*, *vite*\"\r\n",
+			},
+			want: &HoistConfig{
+				Hoist:               true,
+				HoistPatterns:       []string{"*types*", "@babel/*"},
+				PublicHoistPatterns: []string{"*react*", "*vite*"},
+			},
+		},
+		{
+			name: "pnpm without hoisting",
+			pm:   &nodejsPnpm,
+			files: map[string]string{
+				".npmrc": "hoist=false\npublic-hoist-pattern=\n",
+			},
+			want: &HoistConfig{Hoist: false},
+		},
+		{
+			name: "pnpm shamefully-hoist",
+			pm:   &nodejsPnpm,
+			files: map[string]string{
+				".npmrc": "shamefully-hoist=true\npublic-hoist-pattern[]=*eslint*\n",
+			},
+			want: &HoistConfig{
+				Hoist:               true,
+				HoistPatterns:       []string{"*"},
+				PublicHoistPatterns: []string{"*"},
+			},
+		},
+		{
+			name: "berry defaults",
+			pm:   &nodejsBerry,
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: node-modules\n",
+			},
+			want: &HoistConfig{Hoist: true, HoistingLimits: "none", NodeModulesMode: "classic"},
+		},
+		{
+			name: "berry hoisting limits",
+			pm:   &nodejsBerry,
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: node-modules\nnmHoistingLimits: workspaces\nnmMode: hardlinks-local\n",
+			},
+			want: &HoistConfig{Hoist: true, HoistingLimits: "workspaces", NodeModulesMode: "hardlinks-local"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, tt.files)
+			got, err := GetHoistingConfig(rootPath, tt.pm)
+			assert.NilError(t, err, "GetHoistingConfig")
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetHoistingConfig_Errors(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		".npmrc":      "hoist=sometimes\n",
+		".yarnrc.yml": "nmHoistingLimits: everything\n",
+	})
+
+	_, err := GetHoistingConfig(rootPath, &nodejsPnpm)
+	assert.ErrorContains(t, err, `.npmrc: invalid hoist "sometimes"`)
+	_, err = GetHoistingConfig(rootPath, &nodejsBerry)
+	assert.ErrorContains(t, err, `.yarnrc.yml: unknown nmHoistingLimits "everything"`)
+}
@@ -0,0 +1,148 @@
+package packagemanager
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// NodeLinkerStyle describes how a package manager lays out installed dependencies.
+type NodeLinkerStyle string
+
+const (
+	// Hoisted installs dependencies into a flattened node_modules tree.
+	Hoisted NodeLinkerStyle = "hoisted"
+	// Isolated installs dependencies into a content-addressed store and symlinks them into node_modules.
+	Isolated NodeLinkerStyle = "isolated"
+	// PnP resolves dependencies through a generated loader instead of node_modules.
+	PnP NodeLinkerStyle = "pnp"
+)
+
+// NodeModulesLayout returns the layout the package manager produces when it is not configured otherwise.
+func (pm PackageManager) NodeModulesLayout() NodeLinkerStyle {
+	return pm.nodeLinker
+}
+
+// ProjectNodeModulesLayout returns the layout the package manager produces for
+// the project at projectDirectory, honoring any node linker configured there.
+func (pm PackageManager) ProjectNodeModulesLayout(projectDirectory fs.AbsolutePath) (NodeLinkerStyle, error) {
+	if pm.readNodeLinker == nil {
+		return pm.nodeLinker, nil
+	}
+	style, err := pm.readNodeLinker(projectDirectory)
+	if err != nil {
+		return "", err
+	}
+	if style == "" {
+		return pm.nodeLinker, nil
+	}
+	return style, nil
+}
+
+// readNpmrcNodeLinker returns the pnpm node-linker setting from .npmrc, or "" if it is unset.
+func readNpmrcNodeLinker(projectDirectory fs.AbsolutePath) (NodeLinkerStyle, error) {
+	settings, err := readNpmrc(projectDirectory)
+	if err != nil {
+		return "", err
+	}
+
+	value := settings["node-linker"]
+	switch value {
+	case "":
+		return "", nil
+	case "hoisted":
+		return Hoisted, nil
+	case "isolated":
+		return Isolated, nil
+	case "pnp":
+		return PnP, nil
+	default:
+		return "", fmt.Errorf(".npmrc: unknown node-linker %q", value)
+	}
+}
+
+// readNpmrc returns the settings in the .npmrc at projectDirectory, or nil if
+// there is none. Values are not interpolated.
+func readNpmrc(projectDirectory fs.AbsolutePath) (map[string]string, error) {
+	entries, err := readNpmrcEntries(projectDirectory)
+	if err != nil || entries == nil {
+		return nil, err
+	}
+	settings := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		// Later settings win, same as npm's ini parser.
+		settings[entry.key] = entry.value
+	}
+	return settings, nil
+}
+
+// npmrcEntry is a single `key=value` line of an .npmrc.
+type npmrcEntry struct {
+	key   string
+	value string
+}
+
+// readNpmrcEntries returns every setting in the .npmrc at projectDirectory in
+// file order, including repeated keys such as those of `key[]=value` arrays,
+// or nil if there is no .npmrc. Values are not interpolated.
+func readNpmrcEntries(projectDirectory fs.AbsolutePath) ([]npmrcEntry, error) {
+	npmrcPath := projectDirectory.Join(".npmrc")
+	if !npmrcPath.FileExists() {
+		return nil, nil
+	}
+	contents, err := npmrcPath.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf(".npmrc: %w", err)
+	}
+
+	entries := []npmrcEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(normalizeLineEndings(contents)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, val, found := strings.Cut(line, "=")
+		if found {
+			entries = append(entries, npmrcEntry{key: strings.TrimSpace(key), value: strings.TrimSpace(val)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(".npmrc: %w", err)
+	}
+	return entries, nil
+}
+
+// readYarnrcNodeLinker returns the berry nodeLinker setting from .yarnrc.yml, or "" if it is unset.
+func readYarnrcNodeLinker(projectDirectory fs.AbsolutePath) (NodeLinkerStyle, error) {
+	yarnrcPath := projectDirectory.Join(".yarnrc.yml")
+	if !yarnrcPath.FileExists() {
+		return "", nil
+	}
+	contents, err := yarnrcPath.ReadFile()
+	if err != nil {
+		return "", fmt.Errorf(".yarnrc.yml: %w", err)
+	}
+	yarnrc := &util.YarnRC{}
+	if err := yaml.Unmarshal(normalizeLineEndings(contents), yarnrc); err != nil {
+		return "", fmt.Errorf(".yarnrc.yml: %w", err)
+	}
+
+	switch yarnrc.NodeLinker {
+	case "":
+		return "", nil
+	case "node-modules":
+		return Hoisted, nil
+	case "pnpm":
+		return Isolated, nil
+	case "pnp":
+		return PnP, nil
+	default:
+		return "", fmt.Errorf(".yarnrc.yml: unknown nodeLinker %q", yarnrc.NodeLinker)
+	}
+}
@@ -0,0 +1,101 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNodeModulesLayout(t *testing.T) {
+	want := map[string]NodeLinkerStyle{
+		"nodejs-npm":   Hoisted,
+		"nodejs-yarn":  Hoisted,
+		"nodejs-berry": PnP,
+		"nodejs-pnpm":  Isolated,
+		"nodejs-bun":   Hoisted,
+	}
+	for _, pm := range packageManagers {
+		assert.Equal(t, pm.NodeModulesLayout(), want[pm.Name], pm.Name)
+	}
+}
+
+func TestProjectNodeModulesLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		pm      PackageManager
+		files   map[string]string
+		want    NodeLinkerStyle
+		wantErr bool
+	}{
+		{
+			name: "npm ignores configuration",
+			pm:   nodejsNpm,
+			files: map[string]string{
+				".npmrc": "node-linker=isolated\n",
+			},
+			want: Hoisted,
+		},
+		{
+			name: "pnpm default",
+			pm:   nodejsPnpm,
+			want: Isolated,
+		},
+		{
+			name: "pnpm hoisted",
+			pm:   nodejsPnpm,
+			files: map[string]string{
+				".npmrc": "# comment\nauto-install-peers=true\nnode-linker = hoisted\n",
+			},
+			want: Hoisted,
+		},
+		{
+			name: "pnpm unknown node-linker",
+			pm:   nodejsPnpm,
+			files: map[string]string{
+				".npmrc": "node-linker=flat\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "berry default",
+			pm:   nodejsBerry,
+			files: map[string]string{
+				".yarnrc.yml": "yarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+			},
+			want: PnP,
+		},
+		{
+			name: "berry node-modules",
+			pm:   nodejsBerry,
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: node-modules\n",
+			},
+			want: Hoisted,
+		},
+		{
+			name: "berry pnpm",
+			pm:   nodejsBerry,
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: pnpm\n",
+			},
+			want: Isolated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{"package.json": `{"name": "root"}`}
+			for name, contents := range tt.files {
+				files[name] = contents
+			}
+			rootPath := setupFixture(t, files)
+
+			got, err := tt.pm.ProjectNodeModulesLayout(rootPath)
+			if tt.wantErr {
+				assert.Assert(t, err != nil, "expected an error")
+				return
+			}
+			assert.NilError(t, err, "ProjectNodeModulesLayout")
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package packagemanager
+
+import "bytes"
+
+// normalizeLineEndings converts CRLF line endings, as commonly written on
+// Windows, to LF so that configuration files and lockfiles parse the same on
+// every platform. JSON is unaffected by line endings and need not be normalized.
+func normalizeLineEndings(contents []byte) []byte {
+	return bytes.ReplaceAll(contents, []byte("\r\n"), []byte("\n"))
+}
@@ -0,0 +1,69 @@
+package packagemanager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+// withCRLF returns files with every LF line ending replaced by CRLF, as
+// written by editors on Windows.
+func withCRLF(files map[string]string) map[string]string {
+	converted := make(map[string]string, len(files))
+	for name, contents := range files {
+		converted[name] = strings.ReplaceAll(contents, "\n", "\r\n")
+	}
+	return converted
+}
+
+func TestCRLFLineEndings(t *testing.T) {
+	t.Run("pnpm", func(t *testing.T) {
+		rootPath := setupFixture(t, withCRLF(map[string]string{
+			"package.json":             "{\n  \"name\": \"root\",\n  \"packageManager\": \"pnpm@7.9.0\"\n}\n",
+			"pnpm-workspace.yaml":      "packages:\n  - apps/*\n  - packages/*\n",
+			"pnpm-lock.yaml":           pnpmLockfileV6,
+			".npmrc":                   "node-linker=hoisted\n",
+			"apps/web/package.json":    "{\n  \"name\": \"web\"\n}\n",
+			"packages/ui/package.json": "{\n  \"name\": \"ui\"\n}\n",
+		}))
+
+		pkg, err := fs.ReadPackageJSON(rootPath.Join("package.json").ToStringDuringMigration())
+		assert.NilError(t, err, "ReadPackageJSON")
+		packageManager, err := GetPackageManager(rootPath, pkg)
+		assert.NilError(t, err, "GetPackageManager")
+		assert.Equal(t, packageManager.Name, "nodejs-pnpm")
+
+		workspaces, err := packageManager.GetWorkspaces(rootPath)
+		assert.NilError(t, err, "GetWorkspaces")
+		assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{"apps/web/package.json", "packages/ui/package.json"})
+
+		layout, err := packageManager.ProjectNodeModulesLayout(rootPath)
+		assert.NilError(t, err, "ProjectNodeModulesLayout")
+		assert.Equal(t, layout, Hoisted)
+
+		lockfile, err := packageManager.ReadLockfile(rootPath)
+		assert.NilError(t, err, "ReadLockfile")
+		assert.Equal(t, lockfile.FormatVersion(), "6.0")
+	})
+
+	t.Run("berry", func(t *testing.T) {
+		rootPath := setupFixture(t, withCRLF(map[string]string{
+			"package.json": "{\n  \"name\": \"root\"\n}\n",
+			".yarnrc.yml":  "nodeLinker: node-modules\nyarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+			"yarn.lock":    berryLockfile,
+		}))
+
+		assert.Equal(t, detectYarnVariant(rootPath), yarnVariantBerry)
+		layout, err := nodejsBerry.ProjectNodeModulesLayout(rootPath)
+		assert.NilError(t, err, "ProjectNodeModulesLayout")
+		assert.Equal(t, layout, Hoisted)
+
+		want, err := nodejsBerry.parseLockfile([]byte(berryLockfile))
+		assert.NilError(t, err, "parseLockfile")
+		lockfile, err := nodejsBerry.ReadLockfile(rootPath)
+		assert.NilError(t, err, "ReadLockfile")
+		assert.DeepEqual(t, lockfile.WorkspaceDependencies(), want.WorkspaceDependencies())
+	})
+}
@@ -0,0 +1,230 @@
+package packagemanager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// Lockfile is the parsed contents of a Package Manager's lockfile.
+type Lockfile interface {
+	// WorkspaceDependencies returns, for each workspace that depends on other
+	// workspaces, the names of those internal dependencies. Workspaces are
+	// keyed by their slash-separated directory relative to the repository
+	// root, with the root itself keyed as ".".
+	WorkspaceDependencies() map[string][]string
+
+	// FormatVersion returns the lockfile format version declared by the lockfile.
+	FormatVersion() string
+}
+
+// ReadLockfile reads and parses the Package Manager's lockfile at rootpath.
+func (pm PackageManager) ReadLockfile(rootpath fs.AbsolutePath) (Lockfile, error) {
+	if pm.readLockfile != nil {
+		return pm.readLockfile(rootpath)
+	}
+	if pm.parseLockfile == nil {
+		return nil, fmt.Errorf("reading %v is not supported for %v", pm.Lockfile, pm.Name)
+	}
+	contents, err := pm.LockfilePath(rootpath).ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", pm.Lockfile, err)
+	}
+	lockfile, err := pm.parseLockfile(normalizeLineEndings(contents))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", pm.Lockfile, err)
+	}
+	return lockfile, nil
+}
+
+// CheckLockfileCompatibility returns an error if the installed version of the
+// Package Manager is likely too old to read the project's lockfile, based on
+// the lockfile's declared format version. Unknown format versions are not
+// checked.
+func (pm PackageManager) CheckLockfileCompatibility(projectDirectory fs.AbsolutePath) error {
+	lockfile, err := pm.ReadLockfile(projectDirectory)
+	if err != nil {
+		return err
+	}
+	formatVersion := lockfile.FormatVersion()
+	minimum, ok := pm.lockfileMinimumVersions[formatVersion]
+	if !ok {
+		return nil
+	}
+
+	installed, err := pm.GetVersion(projectDirectory.ToStringDuringMigration())
+	if err != nil {
+		return fmt.Errorf("could not determine %v version: %w", pm.Command, err)
+	}
+	installedVersion, err := semver.NewVersion(installed)
+	if err != nil {
+		return fmt.Errorf("could not parse %v version: %w", pm.Command, err)
+	}
+	constraint, err := semver.NewConstraint(">=" + minimum + "-0")
+	if err != nil {
+		return fmt.Errorf("could not create constraint: %w", err)
+	}
+	if !constraint.Check(installedVersion) {
+		return fmt.Errorf("%v has lockfile version %v, which requires %v %v or newer, but %v %v is installed. See %v", pm.Lockfile, formatVersion, pm.Command, minimum, pm.Command, installed, pm.DocsURL)
+	}
+	return nil
+}
+
+// FindStaleLockfiles returns the paths of lockfiles in projectDirectory which
+// belong to a package manager other than resolvedManager, e.g. a yarn.lock
+// left behind after migrating to pnpm.
+func FindStaleLockfiles(projectDirectory fs.AbsolutePath, resolvedManager *PackageManager) ([]string, error) {
+	resolved := resolvedManager.LockfilePath(projectDirectory)
+	seen := make(map[fs.AbsolutePath]bool)
+	var stale []string
+	for _, packageManager := range packageManagers {
+		lockfilePath := packageManager.LockfilePath(projectDirectory)
+		if lockfilePath == resolved || seen[lockfilePath] {
+			continue
+		}
+		seen[lockfilePath] = true
+
+		if _, err := lockfilePath.Lstat(); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("%v: %w", packageManager.Lockfile, err)
+		}
+		stale = append(stale, lockfilePath.ToStringDuringMigration())
+	}
+	return stale, nil
+}
+
+// lockfileHeadSize is how much of a lockfile CheckLockfile reads.
+const lockfileHeadSize = 4096
+
+// CheckLockfile returns a warning if the Package Manager's lockfile is empty
+// or does not start the way the Package Manager writes it, which suggests it
+// was truncated, corrupted, or belongs to a different package manager. A
+// missing or unreadable lockfile produces no warning.
+func (pm PackageManager) CheckLockfile(projectDirectory fs.AbsolutePath) *Warning {
+	if pm.lockfileSignature == nil {
+		return nil
+	}
+	head, err := pm.readLockfileHead(projectDirectory)
+	if err != nil {
+		return nil
+	}
+
+	if len(bytes.TrimSpace(head)) == 0 {
+		return &Warning{
+			Subject: pm.Lockfile,
+			Message: fmt.Sprintf("lockfile is empty. Run `%v install` to regenerate it", pm.Command),
+		}
+	}
+	if !pm.lockfileSignature(normalizeLineEndings(head)) {
+		return &Warning{
+			Subject: pm.Lockfile,
+			Message: fmt.Sprintf("lockfile is not in the format %v writes and may be corrupt. Run `%v install` to regenerate it", pm.Command, pm.Command),
+		}
+	}
+	return nil
+}
+
+// readLockfileHead returns up to lockfileHeadSize bytes from the start of the
+// Package Manager's lockfile, without a byte order mark.
+func (pm PackageManager) readLockfileHead(projectDirectory fs.AbsolutePath) ([]byte, error) {
+	f, err := pm.LockfilePath(projectDirectory).Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	head := make([]byte, lockfileHeadSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return bytes.TrimPrefix(head[:n], []byte("\ufeff")), nil
+}
+
+// GetLockfileVersion returns the format version declared by pm's lockfile in
+// rootpath, such as "2" for npm or "6.0" for pnpm. Only the start of the
+// lockfile is read, so this is much cheaper than ReadLockfile.
+func GetLockfileVersion(rootpath fs.AbsolutePath, pm *PackageManager) (string, error) {
+	if pm.lockfileVersion == nil {
+		return "", fmt.Errorf("reading the lockfile version is not supported for %v", pm.Name)
+	}
+	head, err := pm.readLockfileHead(rootpath)
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", pm.Lockfile, err)
+	}
+	version, ok := pm.lockfileVersion(normalizeLineEndings(head))
+	if !ok {
+		return "", fmt.Errorf("%v: no lockfile version found", pm.Lockfile)
+	}
+	return version, nil
+}
+
+// firstContentLine returns the first line of head which is neither blank nor
+// a `#` comment, with surrounding whitespace removed.
+func firstContentLine(head []byte) string {
+	for _, line := range strings.Split(string(head), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// IsLockfileStale reports whether the root package.json or any workspace
+// manifest was modified more recently than the lockfile, which suggests the
+// lockfile may be out of date. This is a heuristic: touching a manifest
+// without changing its dependencies also makes the lockfile appear stale.
+func (pm PackageManager) IsLockfileStale(rootpath fs.AbsolutePath) (bool, error) {
+	lockfileInfo, err := pm.LockfilePath(rootpath).Lstat()
+	if err != nil {
+		return false, fmt.Errorf("%v: %w", pm.Lockfile, err)
+	}
+
+	workspaces, err := pm.GetWorkspaces(rootpath)
+	if err != nil {
+		return false, err
+	}
+	manifests := append([]string{rootpath.Join("package.json").ToStringDuringMigration()}, workspaces...)
+	for _, manifest := range manifests {
+		info, err := os.Stat(manifest)
+		if err != nil {
+			return false, err
+		}
+		if info.ModTime().After(lockfileInfo.ModTime()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// addWorkspaceDependency records that the workspace at dir depends on the
+// internal package dependency.
+func addWorkspaceDependency(dependencies map[string][]string, dir string, dependency string) {
+	dir = path.Clean(dir)
+	dependencies[dir] = append(dependencies[dir], dependency)
+}
+
+// sortWorkspaceDependencies sorts each workspace's dependency names so that
+// results do not depend on map iteration order.
+func sortWorkspaceDependencies(dependencies map[string][]string) map[string][]string {
+	for _, names := range dependencies {
+		sort.Strings(names)
+	}
+	return dependencies
+}
+
+// isWorkspaceProtocol reports whether a dependency specifier uses the
+// `workspace:` protocol.
+func isWorkspaceProtocol(specifier string) bool {
+	return strings.HasPrefix(specifier, "workspace:")
+}
@@ -0,0 +1,177 @@
+package packagemanager
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BerryLockfile is a representation of a yarn berry yarn.lock, keyed by the
+// comma-separated descriptors which resolve to each entry.
+type BerryLockfile struct {
+	Metadata BerryLockfileMetadata
+	Entries  map[string]*BerryLockfileEntry
+
+	// Resolutions keyed by each descriptor, and entries keyed by resolution,
+	// built on first use by index.
+	descriptors  map[string]string
+	byResolution map[string]*BerryLockfileEntry
+}
+
+// BerryLockfileMetadata is the __metadata section of a berry yarn.lock
+type BerryLockfileMetadata struct {
+	Version  string `yaml:"version"`
+	CacheKey string `yaml:"cacheKey,omitempty"`
+}
+
+// BerryLockfileEntry is a single resolved package in a berry yarn.lock
+type BerryLockfileEntry struct {
+	Version              string            `yaml:"version"`
+	Resolution           string            `yaml:"resolution"`
+	Dependencies         map[string]string `yaml:"dependencies,omitempty"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies,omitempty"`
+}
+
+func parseBerryLockfile(contents []byte) (Lockfile, error) {
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(contents, &raw); err != nil {
+		return nil, err
+	}
+	lockfile := &BerryLockfile{Entries: make(map[string]*BerryLockfileEntry, len(raw))}
+	for key, node := range raw {
+		node := node
+		if key == "__metadata" {
+			if err := node.Decode(&lockfile.Metadata); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var entry BerryLockfileEntry
+		if err := node.Decode(&entry); err != nil {
+			return nil, err
+		}
+		lockfile.Entries[key] = &entry
+	}
+	return lockfile, nil
+}
+
+// FormatVersion returns the __metadata version of yarn.lock
+func (l *BerryLockfile) FormatVersion() string {
+	return l.Metadata.Version
+}
+
+// WorkspaceDependencies returns the dependencies of each workspace entry
+// (those resolved via `workspace:`) which are declared with the `workspace:`
+// protocol.
+func (l *BerryLockfile) WorkspaceDependencies() map[string][]string {
+	dependencies := make(map[string][]string)
+	for _, entry := range l.Entries {
+		dir, ok := berryWorkspaceDir(entry.Resolution)
+		if !ok {
+			continue
+		}
+		for _, section := range []map[string]string{entry.Dependencies, entry.OptionalDependencies} {
+			for name, specifier := range section {
+				if isWorkspaceProtocol(specifier) {
+					addWorkspaceDependency(dependencies, dir, name)
+				}
+			}
+		}
+	}
+	return sortWorkspaceDependencies(dependencies)
+}
+
+// berryLockfileVersion returns the version key of the __metadata entry at the
+// start of a berry lockfile.
+func berryLockfileVersion(head []byte) (string, bool) {
+	inMetadata := false
+	for _, line := range strings.Split(string(head), "\n") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			if inMetadata {
+				return "", false
+			}
+			inMetadata = strings.TrimSpace(line) == "__metadata:"
+			continue
+		}
+		if line = strings.TrimSpace(line); inMetadata && strings.HasPrefix(line, "version:") {
+			version := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "version:")), `'"`)
+			return version, version != ""
+		}
+	}
+	return "", false
+}
+
+// berryWorkspaceDir extracts the workspace directory from a resolution such
+// as `ui@workspace:packages/ui`.
+func berryWorkspaceDir(resolution string) (string, bool) {
+	index := strings.LastIndex(resolution, "@workspace:")
+	if index == -1 {
+		return "", false
+	}
+	return resolution[index+len("@workspace:"):], true
+}
+
+func (l *BerryLockfile) packageEntries() []string {
+	var entries []string
+	for _, entry := range l.Entries {
+		if _, ok := berryWorkspaceDir(entry.Resolution); !ok {
+			entries = append(entries, entry.Resolution)
+		}
+	}
+	return entries
+}
+
+func (l *BerryLockfile) resolveWorkspaceDependency(dir string, name string, specifier string) string {
+	return l.resolve(name, specifier)
+}
+
+func (l *BerryLockfile) entryDependencies(entry string) []string {
+	l.index()
+	candidate, ok := l.byResolution[entry]
+	if !ok {
+		return nil
+	}
+	var dependencies []string
+	for _, section := range []map[string]string{candidate.Dependencies, candidate.OptionalDependencies} {
+		for name, specifier := range section {
+			if dependency := l.resolve(name, specifier); dependency != "" {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	return dependencies
+}
+
+// resolve returns the resolution of the entry for the descriptor
+// `name@specifier`, or "" for a workspace or an unknown descriptor. Berry
+// records semver ranges with an `npm:` protocol which manifests omit.
+func (l *BerryLockfile) resolve(name string, specifier string) string {
+	l.index()
+	for _, descriptor := range []string{name + "@" + specifier, name + "@npm:" + specifier} {
+		if resolution, ok := l.descriptors[descriptor]; ok {
+			if _, isWorkspace := berryWorkspaceDir(resolution); isWorkspace {
+				return ""
+			}
+			return resolution
+		}
+	}
+	return ""
+}
+
+// index builds the lookups used while walking the dependencies of entries.
+func (l *BerryLockfile) index() {
+	if l.descriptors != nil {
+		return
+	}
+	l.descriptors = make(map[string]string)
+	l.byResolution = make(map[string]*BerryLockfileEntry, len(l.Entries))
+	for key, entry := range l.Entries {
+		for _, descriptor := range strings.Split(key, ",") {
+			l.descriptors[strings.TrimSpace(descriptor)] = entry.Resolution
+		}
+		l.byResolution[entry.Resolution] = entry
+	}
+}
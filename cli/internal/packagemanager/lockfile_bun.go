@@ -0,0 +1,256 @@
+package packagemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+const (
+	// bunBinaryLockfile is the binary lockfile written by bun before 1.2.
+	bunBinaryLockfile = "bun.lockb"
+
+	// bunTextLockfile is the text lockfile written by bun 1.1 and later, in
+	// place of the binary bun.lockb.
+	bunTextLockfile = "bun.lock"
+)
+
+// ErrBinaryBunLockfile is matched by the error returned from ParseBunLockfile
+// when the project only has the binary bun.lockb.
+var ErrBinaryBunLockfile = errors.New("bun.lockb is a binary lockfile and cannot be read")
+
+// BunLockfile is a representation of the text bun.lock
+type BunLockfile struct {
+	LockfileVersion int                             `json:"lockfileVersion"`
+	Workspaces      map[string]BunLockfileWorkspace `json:"workspaces,omitempty"`
+
+	// Each package is an array whose first element is its resolution, such as
+	// `react@18.2.0` or `ui@workspace:packages/ui`. The remaining elements
+	// depend on how the package was resolved.
+	Packages map[string][]json.RawMessage `json:"packages,omitempty"`
+}
+
+// BunLockfileWorkspace is a single entry of the bun.lock workspaces section,
+// keyed by the workspace directory with the root as "".
+type BunLockfileWorkspace struct {
+	Name                 string            `json:"name,omitempty"`
+	Version              string            `json:"version,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+}
+
+// ParseBunLockfile reads and parses the text bun.lock at rootpath. If only
+// the binary bun.lockb is present, the error matches ErrBinaryBunLockfile.
+func ParseBunLockfile(rootpath fs.AbsolutePath) (*BunLockfile, error) {
+	contents, err := rootpath.Join(bunTextLockfile).ReadFile()
+	if os.IsNotExist(err) && rootpath.Join(bunBinaryLockfile).FileExists() {
+		return nil, fmt.Errorf("%w. Run `bun install --save-text-lockfile` to generate %v", ErrBinaryBunLockfile, bunTextLockfile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", bunTextLockfile, err)
+	}
+	lockfile, err := parseBunLockfile(normalizeLineEndings(contents))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", bunTextLockfile, err)
+	}
+	return lockfile, nil
+}
+
+func parseBunLockfile(contents []byte) (*BunLockfile, error) {
+	var lockfile BunLockfile
+	if err := json.Unmarshal(stripJSONC(contents), &lockfile); err != nil {
+		return nil, err
+	}
+	return &lockfile, nil
+}
+
+// FormatVersion returns the lockfileVersion of bun.lock
+func (l *BunLockfile) FormatVersion() string {
+	return strconv.Itoa(l.LockfileVersion)
+}
+
+// WorkspaceDependencies returns the dependencies of each workspace which
+// resolve to another workspace, either through the `workspace:` protocol or
+// because bun linked the package to a workspace.
+func (l *BunLockfile) WorkspaceDependencies() map[string][]string {
+	linked := make(map[string]bool)
+	for name, entry := range l.Packages {
+		if len(entry) == 0 {
+			continue
+		}
+		var resolution string
+		if err := json.Unmarshal(entry[0], &resolution); err != nil {
+			continue
+		}
+		if strings.HasPrefix(resolution, name+"@workspace:") {
+			linked[name] = true
+		}
+	}
+
+	dependencies := make(map[string][]string)
+	for key, workspace := range l.Workspaces {
+		dir := key
+		if dir == "" {
+			dir = "."
+		}
+		for _, section := range []map[string]string{workspace.Dependencies, workspace.DevDependencies, workspace.OptionalDependencies} {
+			for name, specifier := range section {
+				if linked[name] || isWorkspaceProtocol(specifier) {
+					addWorkspaceDependency(dependencies, dir, name)
+				}
+			}
+		}
+	}
+	return sortWorkspaceDependencies(dependencies)
+}
+
+// stripJSONC converts the JSON-with-comments written by bun into JSON by
+// removing comments and trailing commas outside of strings.
+func stripJSONC(contents []byte) []byte {
+	out := make([]byte, 0, len(contents))
+	// The index in out of a comma which may turn out to be trailing.
+	pendingComma := -1
+	for i := 0; i < len(contents); i++ {
+		c := contents[i]
+		switch {
+		case c == '"':
+			start := i
+			for i++; i < len(contents) && contents[i] != '"'; i++ {
+				if contents[i] == '\\' {
+					i++
+				}
+			}
+			end := i + 1
+			if end > len(contents) {
+				end = len(contents)
+			}
+			out = append(out, contents[start:end]...)
+			pendingComma = -1
+		case c == '/' && i+1 < len(contents) && contents[i+1] == '/':
+			for i < len(contents) && contents[i] != '\n' {
+				i++
+			}
+			if i < len(contents) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(contents) && contents[i+1] == '*':
+			end := strings.Index(string(contents[i+2:]), "*/")
+			if end < 0 {
+				i = len(contents)
+			} else {
+				i += end + 3
+			}
+		case c == ',':
+			pendingComma = len(out)
+			out = append(out, c)
+		case c == '}' || c == ']':
+			if pendingComma >= 0 {
+				out = append(out[:pendingComma], out[pendingComma+1:]...)
+				pendingComma = -1
+			}
+			out = append(out, c)
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out = append(out, c)
+		default:
+			pendingComma = -1
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (l *BunLockfile) packageEntries() []string {
+	var entries []string
+	for key, entry := range l.Packages {
+		if len(entry) == 0 {
+			continue
+		}
+		var resolution string
+		if err := json.Unmarshal(entry[0], &resolution); err == nil && strings.Contains(resolution, "@workspace:") {
+			continue
+		}
+		entries = append(entries, key)
+	}
+	return entries
+}
+
+func (l *BunLockfile) resolveWorkspaceDependency(dir string, name string, specifier string) string {
+	if dir == "." {
+		dir = ""
+	}
+	// Packages only one workspace could use are keyed below its name.
+	if workspace, ok := l.Workspaces[dir]; ok && workspace.Name != "" {
+		if _, ok := l.Packages[workspace.Name+"/"+name]; ok {
+			return workspace.Name + "/" + name
+		}
+	}
+	return l.lookup("", name)
+}
+
+func (l *BunLockfile) entryDependencies(entry string) []string {
+	// The third element records the dependencies of packages from a registry.
+	if len(l.Packages[entry]) < 3 {
+		return nil
+	}
+	var info struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal(l.Packages[entry][2], &info); err != nil {
+		return nil
+	}
+	var dependencies []string
+	for _, section := range []map[string]string{info.Dependencies, info.OptionalDependencies, info.PeerDependencies} {
+		for name := range section {
+			if dependency := l.lookup(entry, name); dependency != "" {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	return dependencies
+}
+
+// lookup returns the key of the package which name resolves to from the
+// package at key, which nests conflicting versions as `<parent>/<name>`, or ""
+// for a workspace or a package the lockfile does not have.
+func (l *BunLockfile) lookup(key string, name string) string {
+	parents := bunKeyNames(key)
+	for i := len(parents); i >= 0; i-- {
+		candidate := strings.Join(append(append([]string{}, parents[:i]...), name), "/")
+		if entry, ok := l.Packages[candidate]; ok {
+			var resolution string
+			if len(entry) > 0 && json.Unmarshal(entry[0], &resolution) == nil && strings.Contains(resolution, "@workspace:") {
+				return ""
+			}
+			return candidate
+		}
+	}
+	return ""
+}
+
+// bunKeyNames splits a bun.lock package key into the package names it nests,
+// keeping scoped names such as `@scope/name` whole.
+func bunKeyNames(key string) []string {
+	if key == "" {
+		return nil
+	}
+	var names []string
+	segments := strings.Split(key, "/")
+	for i := 0; i < len(segments); i++ {
+		if strings.HasPrefix(segments[i], "@") && i+1 < len(segments) {
+			names = append(names, segments[i]+"/"+segments[i+1])
+			i++
+			continue
+		}
+		names = append(names, segments[i])
+	}
+	return names
+}
@@ -0,0 +1,81 @@
+package packagemanager
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver"
+)
+
+// LockfileFormat identifies an on-disk lockfile format. Package Managers
+// which write the same format can reuse one another's lockfiles.
+type LockfileFormat string
+
+const (
+	// LockfileFormatUnknown is the format of a Package Manager without a lockfile.
+	LockfileFormatUnknown LockfileFormat = ""
+	// LockfileFormatYarnV1 is the yarn.lock written by yarn classic.
+	LockfileFormatYarnV1 LockfileFormat = "yarn-v1"
+	// LockfileFormatBerry is the YAML yarn.lock written by yarn 2 and later.
+	LockfileFormatBerry LockfileFormat = "berry"
+	// LockfileFormatNpmV1 is lockfileVersion 1 of package-lock.json, written by npm 6 and earlier.
+	LockfileFormatNpmV1 LockfileFormat = "npm-v1"
+	// LockfileFormatNpmV2 is lockfileVersion 2 of package-lock.json, written by npm 7 and 8.
+	LockfileFormatNpmV2 LockfileFormat = "npm-v2"
+	// LockfileFormatNpmV3 is lockfileVersion 3 of package-lock.json, written by npm 9 and later.
+	LockfileFormatNpmV3 LockfileFormat = "npm-v3"
+	// LockfileFormatPnpmV5 is lockfileVersion 5.x of pnpm-lock.yaml, written by pnpm 7 and earlier.
+	LockfileFormatPnpmV5 LockfileFormat = "pnpm-v5"
+	// LockfileFormatPnpmV6 is lockfileVersion 6.0 of pnpm-lock.yaml, written by pnpm 8.
+	LockfileFormatPnpmV6 LockfileFormat = "pnpm-v6"
+	// LockfileFormatPnpmV9 is lockfileVersion 9.0 of pnpm-lock.yaml, written by pnpm 9 and later.
+	LockfileFormatPnpmV9 LockfileFormat = "pnpm-v9"
+	// LockfileFormatBunBinary is the binary bun.lockb written by bun before 1.2.
+	LockfileFormatBunBinary LockfileFormat = "bun-binary"
+	// LockfileFormatBunText is the text bun.lock written by bun 1.2 and later.
+	LockfileFormatBunText LockfileFormat = "bun-text"
+)
+
+// versionedLockfileFormat is the lockfile format written by Package Manager
+// releases from since onwards.
+type versionedLockfileFormat struct {
+	since  string
+	format LockfileFormat
+}
+
+// LockfileFormat returns the lockfile format written by current releases of
+// the Package Manager. It distinguishes yarn classic from berry, which share
+// the yarn.lock file name but not its format.
+func (pm PackageManager) LockfileFormat() LockfileFormat {
+	if len(pm.lockfileFormats) == 0 {
+		return LockfileFormatUnknown
+	}
+	return pm.lockfileFormats[len(pm.lockfileFormats)-1].format
+}
+
+// LockfileFormatForVersion returns the lockfile format written by version of
+// the Package Manager.
+func (pm PackageManager) LockfileFormatForVersion(version string) (LockfileFormat, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return LockfileFormatUnknown, fmt.Errorf("could not parse %v version %q: %w", pm.Command, version, err)
+	}
+	format := LockfileFormatUnknown
+	for _, candidate := range pm.lockfileFormats {
+		constraint, err := semver.NewConstraint(">=" + candidate.since + "-0")
+		if err != nil {
+			return LockfileFormatUnknown, fmt.Errorf("could not create constraint: %w", err)
+		}
+		if constraint.Check(v) {
+			format = candidate.format
+		}
+	}
+	return format, nil
+}
+
+// AreLockfilesCompatible reports whether current releases of a and b write
+// the same lockfile format, so that a lockfile written by one can be reused
+// by the other.
+func AreLockfilesCompatible(a, b *PackageManager) bool {
+	format := a.LockfileFormat()
+	return format != LockfileFormatUnknown && format == b.LockfileFormat()
+}
@@ -0,0 +1,126 @@
+package packagemanager
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// NpmLockfile is a representation of package-lock.json. Only lockfile
+// versions 2 and above record the `packages` section.
+type NpmLockfile struct {
+	LockfileVersion int                         `json:"lockfileVersion"`
+	Packages        map[string]NpmLockfileEntry `json:"packages,omitempty"`
+}
+
+// NpmLockfileEntry is a single entry of the package-lock.json packages section
+type NpmLockfileEntry struct {
+	Name                 string            `json:"name,omitempty"`
+	Version              string            `json:"version,omitempty"`
+	Resolved             string            `json:"resolved,omitempty"`
+	Link                 bool              `json:"link,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+}
+
+func parseNpmLockfile(contents []byte) (Lockfile, error) {
+	var lockfile NpmLockfile
+	if err := json.Unmarshal(contents, &lockfile); err != nil {
+		return nil, err
+	}
+	return &lockfile, nil
+}
+
+// FormatVersion returns the lockfileVersion of package-lock.json
+func (l *NpmLockfile) FormatVersion() string {
+	return strconv.Itoa(l.LockfileVersion)
+}
+
+// WorkspaceDependencies returns the dependencies which npm linked to another
+// workspace. npm has no workspace protocol; instead each workspace is linked
+// into the root node_modules.
+func (l *NpmLockfile) WorkspaceDependencies() map[string][]string {
+	linked := make(map[string]bool)
+	for key, entry := range l.Packages {
+		if entry.Link && strings.HasPrefix(key, "node_modules/") {
+			linked[strings.TrimPrefix(key, "node_modules/")] = true
+		}
+	}
+
+	dependencies := make(map[string][]string)
+	for key, entry := range l.Packages {
+		if strings.Contains(key, "node_modules/") {
+			continue
+		}
+		dir := key
+		if dir == "" {
+			dir = "."
+		}
+		for _, section := range []map[string]string{entry.Dependencies, entry.DevDependencies, entry.OptionalDependencies} {
+			for name, specifier := range section {
+				if linked[name] || isWorkspaceProtocol(specifier) {
+					addWorkspaceDependency(dependencies, dir, name)
+				}
+			}
+		}
+	}
+	return sortWorkspaceDependencies(dependencies)
+}
+
+func (l *NpmLockfile) packageEntries() []string {
+	var entries []string
+	for key, entry := range l.Packages {
+		if strings.Contains(key, "node_modules/") && !entry.Link {
+			entries = append(entries, key)
+		}
+	}
+	return entries
+}
+
+func (l *NpmLockfile) resolveWorkspaceDependency(dir string, name string, specifier string) string {
+	if dir == "." {
+		dir = ""
+	}
+	return l.lookup(dir, name)
+}
+
+func (l *NpmLockfile) entryDependencies(entry string) []string {
+	var dependencies []string
+	for _, section := range []map[string]string{l.Packages[entry].Dependencies, l.Packages[entry].OptionalDependencies, l.Packages[entry].PeerDependencies} {
+		for name := range section {
+			if dependency := l.lookup(entry, name); dependency != "" {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	return dependencies
+}
+
+// lookup returns the entry which node resolves name to from the package at
+// key: the nearest node_modules/name in key or any of its ancestors, ending
+// with the root. It returns "" for a link to a workspace or if there is none.
+func (l *NpmLockfile) lookup(key string, name string) string {
+	for {
+		candidate := "node_modules/" + name
+		if key != "" {
+			candidate = key + "/" + candidate
+		}
+		if entry, ok := l.Packages[candidate]; ok {
+			if entry.Link {
+				return ""
+			}
+			return candidate
+		}
+		if key == "" {
+			return ""
+		}
+		// Workspaces outside node_modules resolve from the root next.
+		index := strings.LastIndex(key, "node_modules/")
+		if index == -1 {
+			index = 0
+		}
+		key = strings.TrimSuffix(key[:index], "/")
+	}
+}
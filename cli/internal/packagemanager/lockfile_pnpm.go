@@ -0,0 +1,147 @@
+package packagemanager
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PnpmLockfile is a representation of pnpm-lock.yaml
+type PnpmLockfile struct {
+	LockfileVersion string                  `yaml:"lockfileVersion"`
+	Importers       map[string]PnpmImporter `yaml:"importers,omitempty"`
+	Packages        map[string]PnpmPackage  `yaml:"packages,omitempty"`
+
+	// Lockfile v9 moves the dependencies of each package from packages to
+	// snapshots, keyed with any peer dependency suffix.
+	Snapshots map[string]PnpmPackage `yaml:"snapshots,omitempty"`
+}
+
+// PnpmPackage is an entry of the pnpm-lock.yaml packages or snapshots section
+type PnpmPackage struct {
+	Dependencies         map[string]string `yaml:"dependencies,omitempty"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies,omitempty"`
+}
+
+// PnpmImporter is the section of pnpm-lock.yaml describing a single workspace
+type PnpmImporter struct {
+	Dependencies         map[string]PnpmDependency `yaml:"dependencies,omitempty"`
+	DevDependencies      map[string]PnpmDependency `yaml:"devDependencies,omitempty"`
+	OptionalDependencies map[string]PnpmDependency `yaml:"optionalDependencies,omitempty"`
+}
+
+// PnpmDependency is a resolved importer dependency. Lockfiles prior to v6
+// record only the resolved version, while later versions also inline the
+// specifier from package.json.
+type PnpmDependency struct {
+	Specifier string `yaml:"specifier,omitempty"`
+	Version   string `yaml:"version"`
+}
+
+// UnmarshalYAML accepts both the scalar and mapping forms of a dependency.
+func (d *PnpmDependency) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		d.Version = value.Value
+		return nil
+	}
+	type plain PnpmDependency
+	return value.Decode((*plain)(d))
+}
+
+func parsePnpmLockfile(contents []byte) (Lockfile, error) {
+	var lockfile PnpmLockfile
+	if err := yaml.Unmarshal(contents, &lockfile); err != nil {
+		return nil, err
+	}
+	return &lockfile, nil
+}
+
+// FormatVersion returns the lockfileVersion of pnpm-lock.yaml
+func (l *PnpmLockfile) FormatVersion() string {
+	return l.LockfileVersion
+}
+
+// WorkspaceDependencies returns the dependencies which pnpm resolved to
+// another workspace via a `link:` version.
+func (l *PnpmLockfile) WorkspaceDependencies() map[string][]string {
+	dependencies := make(map[string][]string)
+	for dir, importer := range l.Importers {
+		for _, section := range []map[string]PnpmDependency{importer.Dependencies, importer.DevDependencies, importer.OptionalDependencies} {
+			for name, dependency := range section {
+				if strings.HasPrefix(dependency.Version, "link:") || isWorkspaceProtocol(dependency.Specifier) {
+					addWorkspaceDependency(dependencies, dir, name)
+				}
+			}
+		}
+	}
+	return sortWorkspaceDependencies(dependencies)
+}
+
+// entries returns the section which records the dependencies of each package.
+func (l *PnpmLockfile) entries() map[string]PnpmPackage {
+	if l.Snapshots != nil {
+		return l.Snapshots
+	}
+	return l.Packages
+}
+
+func (l *PnpmLockfile) packageEntries() []string {
+	var entries []string
+	for key := range l.entries() {
+		entries = append(entries, key)
+	}
+	return entries
+}
+
+func (l *PnpmLockfile) resolveWorkspaceDependency(dir string, name string, specifier string) string {
+	importer := l.Importers[dir]
+	for _, section := range []map[string]PnpmDependency{importer.Dependencies, importer.DevDependencies, importer.OptionalDependencies} {
+		if dependency, ok := section[name]; ok {
+			return l.entryKey(name, dependency.Version)
+		}
+	}
+	return ""
+}
+
+func (l *PnpmLockfile) entryDependencies(entry string) []string {
+	var dependencies []string
+	for _, section := range []map[string]string{l.entries()[entry].Dependencies, l.entries()[entry].OptionalDependencies} {
+		for name, version := range section {
+			if dependency := l.entryKey(name, version); dependency != "" {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	return dependencies
+}
+
+// entryKey returns the key of the entry for name resolved to version, such as
+// `/react/18.2.0` in v5, `/react@18.2.0` in v6, or `react@18.2.0` in v9.
+// Versions which are themselves keys, as for aliases, are returned as they
+// are. It returns "" for links and for entries the lockfile does not have.
+func (l *PnpmLockfile) entryKey(name string, version string) string {
+	if strings.HasPrefix(version, "link:") {
+		return ""
+	}
+	key := version
+	switch {
+	case strings.HasPrefix(l.LockfileVersion, "5"):
+		if !strings.HasPrefix(version, "/") {
+			key = "/" + name + "/" + version
+		}
+	case l.Snapshots == nil:
+		if !strings.HasPrefix(version, "/") {
+			key = "/" + name + "@" + version
+		}
+	default:
+		// An alias resolves to `<name>@<version>` of the aliased package.
+		unsuffixed, _, _ := strings.Cut(version, "(")
+		if strings.LastIndex(unsuffixed, "@") <= 0 {
+			key = name + "@" + version
+		}
+	}
+	if _, ok := l.entries()[key]; !ok {
+		return ""
+	}
+	return key
+}
@@ -0,0 +1,488 @@
+package packagemanager
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"gotest.tools/v3/assert"
+)
+
+const pnpmLockfileV5 = `lockfileVersion: 5.4
+
+importers:
+
+  .:
+    specifiers:
+      turbo: latest
+    devDependencies:
+      turbo: 1.4.0
+
+  apps/web:
+    specifiers:
+      next: 12.2.5
+      ui: workspace:*
+    dependencies:
+      next: 12.2.5
+      ui: link:../../packages/ui
+
+  packages/ui:
+    specifiers:
+      react: ^18.2.0
+    devDependencies:
+      react: 18.2.0
+`
+
+const pnpmLockfileV6 = `lockfileVersion: '6.0'
+
+importers:
+
+  .:
+    devDependencies:
+      turbo:
+        specifier: latest
+        version: 1.10.0
+
+  apps/web:
+    dependencies:
+      next:
+        specifier: 13.4.0
+        version: 13.4.0
+      ui:
+        specifier: workspace:*
+        version: link:../../packages/ui
+    devDependencies:
+      tsconfig:
+        specifier: workspace:^
+        version: link:../../packages/tsconfig
+`
+
+const berryLockfile = `# This file is generated by running "yarn install" inside your project.
+# Manual changes might be lost - proceed with caution!
+
+__metadata:
+  version: 6
+  cacheKey: 8
+
+"react@npm:^18.2.0":
+  version: 18.2.0
+  resolution: "react@npm:18.2.0"
+  dependencies:
+    loose-envify: ^1.1.0
+
+"root@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "root@workspace:."
+  languageName: unknown
+  linkType: soft
+
+"ui@workspace:*, ui@workspace:packages/ui":
+  version: 0.0.0-use.local
+  resolution: "ui@workspace:packages/ui"
+  dependencies:
+    react: ^18.2.0
+    tsconfig: "workspace:*"
+  languageName: unknown
+  linkType: soft
+
+"web@workspace:apps/web":
+  version: 0.0.0-use.local
+  resolution: "web@workspace:apps/web"
+  dependencies:
+    react: ^18.2.0
+    ui: "workspace:*"
+  languageName: unknown
+  linkType: soft
+`
+
+const npmLockfile = `{
+  "name": "root",
+  "lockfileVersion": 2,
+  "requires": true,
+  "packages": {
+    "": {
+      "name": "root",
+      "workspaces": ["apps/*", "packages/*"],
+      "devDependencies": {"turbo": "latest"}
+    },
+    "apps/web": {
+      "name": "web",
+      "dependencies": {"next": "12.2.5", "ui": "*"}
+    },
+    "packages/ui": {
+      "name": "ui",
+      "devDependencies": {"react": "^18.2.0"}
+    },
+    "node_modules/next": {"version": "12.2.5"},
+    "node_modules/react": {"version": "18.2.0"},
+    "node_modules/turbo": {"version": "1.4.0"},
+    "node_modules/ui": {"resolved": "packages/ui", "link": true},
+    "node_modules/web": {"resolved": "apps/web", "link": true}
+  }
+}`
+
+func TestWorkspaceDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		pm       PackageManager
+		lockfile string
+		want     map[string][]string
+	}{
+		{
+			name:     "pnpm v5",
+			pm:       nodejsPnpm,
+			lockfile: pnpmLockfileV5,
+			want:     map[string][]string{"apps/web": {"ui"}},
+		},
+		{
+			name:     "pnpm v6",
+			pm:       nodejsPnpm,
+			lockfile: pnpmLockfileV6,
+			want:     map[string][]string{"apps/web": {"tsconfig", "ui"}},
+		},
+		{
+			name:     "berry",
+			pm:       nodejsBerry,
+			lockfile: berryLockfile,
+			want:     map[string][]string{"apps/web": {"ui"}, "packages/ui": {"tsconfig"}},
+		},
+		{
+			name:     "npm",
+			pm:       nodejsNpm,
+			lockfile: npmLockfile,
+			want:     map[string][]string{"apps/web": {"ui"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{tt.pm.Lockfile: tt.lockfile})
+			lockfile, err := tt.pm.ReadLockfile(rootPath)
+			assert.NilError(t, err, "ReadLockfile")
+			assert.DeepEqual(t, lockfile.WorkspaceDependencies(), tt.want)
+		})
+	}
+}
+
+func TestReadLockfile_Unsupported(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{"yarn.lock": "# yarn lockfile v1\n"})
+	_, err := nodejsYarn.ReadLockfile(rootPath)
+	assert.ErrorContains(t, err, "reading yarn.lock is not supported for nodejs-yarn")
+}
+
+func TestCheckLockfileCompatibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		pm        PackageManager
+		lockfile  string
+		installed string
+		wantErr   string
+	}{
+		{
+			name:      "pnpm v6 lockfile with pnpm 8",
+			pm:        nodejsPnpm,
+			lockfile:  pnpmLockfileV6,
+			installed: "8.6.0",
+		},
+		{
+			name:      "pnpm v9 lockfile with pnpm 7",
+			pm:        nodejsPnpm,
+			lockfile:  "lockfileVersion: '9.0'\n",
+			installed: "7.33.0",
+			wantErr:   "pnpm-lock.yaml has lockfile version 9.0, which requires pnpm 9.0.0 or newer, but pnpm 7.33.0 is installed",
+		},
+		{
+			name:      "npm v2 lockfile with npm 6",
+			pm:        nodejsNpm,
+			lockfile:  npmLockfile,
+			installed: "6.14.17",
+			wantErr:   "requires npm 7.0.0 or newer",
+		},
+		{
+			name:      "berry v6 lockfile with yarn 3.2",
+			pm:        nodejsBerry,
+			lockfile:  berryLockfile,
+			installed: "3.2.1",
+		},
+		{
+			name:      "unknown lockfile version",
+			pm:        nodejsPnpm,
+			lockfile:  "lockfileVersion: '42.0'\n",
+			installed: "7.33.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeVersionCommands(t, map[string]string{tt.pm.Command: tt.installed})
+			rootPath := setupFixture(t, map[string]string{tt.pm.Lockfile: tt.lockfile})
+
+			err := tt.pm.CheckLockfileCompatibility(rootPath)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err, "CheckLockfileCompatibility")
+		})
+	}
+}
+
+func TestFindStaleLockfiles(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":   `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+		"pnpm-lock.yaml": pnpmLockfileV6,
+		"yarn.lock":      "# yarn lockfile v1\n",
+	})
+
+	stale, err := FindStaleLockfiles(rootPath, &nodejsPnpm)
+	assert.NilError(t, err, "FindStaleLockfiles")
+	assert.DeepEqual(t, stale, []string{rootPath.Join("yarn.lock").ToStringDuringMigration()})
+
+	stale, err = FindStaleLockfiles(rootPath, &nodejsBerry)
+	assert.NilError(t, err, "FindStaleLockfiles")
+	assert.DeepEqual(t, stale, []string{rootPath.Join("pnpm-lock.yaml").ToStringDuringMigration()})
+}
+
+const bunLockfile = `{
+  "lockfileVersion": 0,
+  "workspaces": {
+    "": {
+      "name": "root",
+      "devDependencies": {
+        "turbo": "latest",
+      },
+    },
+    "apps/web": {
+      "name": "web",
+      "dependencies": {
+        // Linked by bun without the workspace protocol.
+        "tsconfig": "*",
+        "ui": "workspace:*",
+        "react": "^18.2.0",
+      },
+    },
+    "packages/tsconfig": {
+      "name": "tsconfig",
+    },
+    "packages/ui": {
+      "name": "ui",
+      "peerDependencies": {
+        "react": "^18.2.0",
+      },
+    },
+  },
+  "packages": {
+    "react": ["react@18.2.0", "", { "dependencies": { "loose-envify": "^1.1.0" } }, "sha512-/3IjMdb2L9QbBdWiW5e3P2/npwMBaU9mHCSCUzNln0ZCYbcfTsGbTJrU/kGemdH2IWmB2ioZ+zkxtmq6g09fGQ=="],
+    "telemetry": ["telemetry@1.0.0", "", {}, "sha512-a//b/c+d=="],
+    "tsconfig": ["tsconfig@workspace:packages/tsconfig"],
+    "turbo": ["turbo@1.10.0", "", {}, "sha512-x"],
+    "ui": ["ui@workspace:packages/ui"],
+  }
+}
+`
+
+func TestParseBunLockfile(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"bun.lock":  bunLockfile,
+		"bun.lockb": "\x00binary",
+	})
+
+	lockfile, err := ParseBunLockfile(rootPath)
+	assert.NilError(t, err, "ParseBunLockfile")
+	assert.Equal(t, lockfile.FormatVersion(), "0")
+	assert.Equal(t, lockfile.Workspaces["apps/web"].Name, "web")
+
+	read, err := nodejsBun.ReadLockfile(rootPath)
+	assert.NilError(t, err, "ReadLockfile")
+	assert.DeepEqual(t, read.WorkspaceDependencies(), map[string][]string{"apps/web": {"tsconfig", "ui"}})
+}
+
+func TestParseBunLockfile_BinaryOnly(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{"bun.lockb": "\x00binary"})
+	_, err := ParseBunLockfile(rootPath)
+	assert.ErrorIs(t, err, ErrBinaryBunLockfile)
+	assert.ErrorContains(t, err, "bun install --save-text-lockfile")
+
+	rootPath = setupFixture(t, map[string]string{})
+	_, err = ParseBunLockfile(rootPath)
+	assert.ErrorContains(t, err, "bun.lock: ")
+}
+
+func Test_stripJSONC(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: `{"a": [1, 2,],}`, want: `{"a": [1, 2]}`},
+		{input: "{\"a\": 1, // comment\n}", want: "{\"a\": 1 \n}"},
+		{input: `{/* block */"a": "x,}//y"}`, want: `{"a": "x,}//y"}`},
+		{input: `{"a": "quote \" ,]"}`, want: `{"a": "quote \" ,]"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, string(stripJSONC([]byte(tt.input))), tt.want)
+		})
+	}
+}
+
+func TestIsLockfileStale(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["packages/*"]}`,
+		"package-lock.json":        npmLockfile,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+	lockfileTime := time.Now().Add(-time.Hour)
+	setMtime := func(path string, mtime time.Time) {
+		t.Helper()
+		assert.NilError(t, os.Chtimes(rootPath.Join(path).ToStringDuringMigration(), mtime, mtime), "Chtimes")
+	}
+	setMtime("package-lock.json", lockfileTime)
+
+	setMtime("package.json", lockfileTime.Add(-time.Minute))
+	setMtime("packages/ui/package.json", lockfileTime.Add(-time.Minute))
+	stale, err := nodejsNpm.IsLockfileStale(rootPath)
+	assert.NilError(t, err, "IsLockfileStale")
+	assert.Assert(t, !stale, "lockfile newer than every manifest")
+
+	setMtime("packages/ui/package.json", lockfileTime.Add(time.Minute))
+	stale, err = nodejsNpm.IsLockfileStale(rootPath)
+	assert.NilError(t, err, "IsLockfileStale")
+	assert.Assert(t, stale, "workspace manifest newer than the lockfile")
+
+	setMtime("packages/ui/package.json", lockfileTime.Add(-time.Minute))
+	setMtime("package.json", lockfileTime.Add(time.Minute))
+	stale, err = nodejsNpm.IsLockfileStale(rootPath)
+	assert.NilError(t, err, "IsLockfileStale")
+	assert.Assert(t, stale, "root manifest newer than the lockfile")
+
+	_, err = nodejsPnpm.IsLockfileStale(rootPath)
+	assert.ErrorContains(t, err, "pnpm-lock.yaml: ")
+}
+
+func TestCheckLockfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		pm       PackageManager
+		lockfile string
+		want     string
+	}{
+		{name: "pnpm", pm: nodejsPnpm, lockfile: pnpmLockfileV6},
+		{name: "pnpm empty", pm: nodejsPnpm, lockfile: "", want: "pnpm-lock.yaml: lockfile is empty"},
+		{name: "pnpm whitespace", pm: nodejsPnpm, lockfile: "\n\n", want: "pnpm-lock.yaml: lockfile is empty"},
+		{name: "pnpm corrupt", pm: nodejsPnpm, lockfile: "<<<<<<< HEAD\nlockfileVersion: 5.4\n", want: "not in the format pnpm writes"},
+		{name: "npm", pm: nodejsNpm, lockfile: npmLockfile},
+		{name: "npm corrupt", pm: nodejsNpm, lockfile: "\x00\x00\x00", want: "package-lock.json: lockfile is not in the format npm writes"},
+		{name: "berry", pm: nodejsBerry, lockfile: berryLockfile},
+		{name: "yarn", pm: nodejsYarn, lockfile: "# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.\n# yarn lockfile v1\n\n"},
+		{name: "yarn given a berry lockfile", pm: nodejsYarn, lockfile: berryLockfile, want: "not in the format yarn writes"},
+		{name: "bun", pm: nodejsBun, lockfile: "#!/usr/bin/env bun\nbun-lockfile-format-v0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{tt.pm.Lockfile: tt.lockfile})
+			warning := tt.pm.CheckLockfile(rootPath)
+			if tt.want == "" {
+				assert.Assert(t, warning == nil, "unexpected warning %v", warning)
+				return
+			}
+			assert.Assert(t, warning != nil, "expected a warning")
+			assert.Assert(t, strings.Contains(warning.String(), tt.want), warning.String())
+		})
+	}
+
+	assert.Assert(t, nodejsPnpm.CheckLockfile(setupFixture(t, map[string]string{})) == nil, "missing lockfile")
+}
+
+func TestGetPackageManager_LogsCorruptLockfile(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":   `{"name": "root"}`,
+		"pnpm-lock.yaml": "",
+	})
+	var output bytes.Buffer
+	logger := hclog.New(&hclog.LoggerOptions{Output: &output})
+
+	got, err := GetPackageManagerWithOpts(rootPath, nil, Opts{Logger: logger})
+	assert.NilError(t, err, "GetPackageManagerWithOpts")
+	assert.Equal(t, got.Name, "nodejs-pnpm")
+	assert.Assert(t, strings.Contains(output.String(), "pnpm-lock.yaml: lockfile is empty"), output.String())
+}
+
+func TestGetLockfileVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		pm       PackageManager
+		lockfile string
+		want     string
+		wantErr  string
+	}{
+		{name: "npm", pm: nodejsNpm, lockfile: npmLockfile, want: "2"},
+		{name: "pnpm v5", pm: nodejsPnpm, lockfile: pnpmLockfileV5, want: "5.4"},
+		{name: "pnpm v6", pm: nodejsPnpm, lockfile: pnpmLockfileV6, want: "6.0"},
+		{name: "berry", pm: nodejsBerry, lockfile: berryLockfile, want: "6"},
+		{name: "yarn", pm: nodejsYarn, lockfile: "# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.\n# yarn lockfile v1\n\n", want: "1"},
+		{name: "npm without a version", pm: nodejsNpm, lockfile: `{"name": "root"}`, wantErr: "package-lock.json: no lockfile version found"},
+		{name: "berry without metadata", pm: nodejsBerry, lockfile: "\"react@npm:^18.2.0\":\n  version: 18.2.0\n", wantErr: "yarn.lock: no lockfile version found"},
+		{name: "bun", pm: nodejsBun, lockfile: "#!/usr/bin/env bun\nbun-lockfile-format-v0\n", wantErr: "not supported for nodejs-bun"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{tt.pm.Lockfile: tt.lockfile})
+			got, err := GetLockfileVersion(rootPath, &tt.pm)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err, "GetLockfileVersion")
+			assert.Equal(t, got, tt.want)
+		})
+	}
+
+	_, err := GetLockfileVersion(setupFixture(t, map[string]string{}), &nodejsPnpm)
+	assert.ErrorContains(t, err, "pnpm-lock.yaml: ")
+}
+
+func TestLockfileFormat(t *testing.T) {
+	assert.Equal(t, nodejsYarn.LockfileFormat(), LockfileFormatYarnV1)
+	assert.Equal(t, nodejsBerry.LockfileFormat(), LockfileFormatBerry)
+	assert.Equal(t, nodejsNpm.LockfileFormat(), LockfileFormatNpmV3)
+	assert.Equal(t, nodejsPnpm.LockfileFormat(), LockfileFormatPnpmV9)
+	assert.Equal(t, nodejsBun.LockfileFormat(), LockfileFormatBunText)
+
+	// yarn classic and berry share yarn.lock but not its format.
+	assert.Assert(t, !AreLockfilesCompatible(&nodejsYarn, &nodejsBerry))
+	assert.Assert(t, !AreLockfilesCompatible(&nodejsBerry, &nodejsYarn))
+	assert.Assert(t, AreLockfilesCompatible(&nodejsYarn, &nodejsYarn))
+	assert.Assert(t, AreLockfilesCompatible(&nodejsBerry, &nodejsBerry))
+	assert.Assert(t, !AreLockfilesCompatible(&nodejsNpm, &nodejsPnpm))
+	assert.Assert(t, !AreLockfilesCompatible(&PackageManager{}, &PackageManager{}))
+}
+
+func TestLockfileFormatForVersion(t *testing.T) {
+	tests := []struct {
+		pm      PackageManager
+		version string
+		want    LockfileFormat
+	}{
+		{nodejsYarn, "1.22.19", LockfileFormatYarnV1},
+		{nodejsBerry, "3.2.3", LockfileFormatBerry},
+		{nodejsBerry, "4.0.0-rc.1", LockfileFormatBerry},
+		{nodejsBerry, "1.22.19", LockfileFormatUnknown},
+		{nodejsNpm, "6.14.18", LockfileFormatNpmV1},
+		{nodejsNpm, "8.19.2", LockfileFormatNpmV2},
+		{nodejsNpm, "10.2.0", LockfileFormatNpmV3},
+		{nodejsPnpm, "7.33.0", LockfileFormatPnpmV5},
+		{nodejsPnpm, "8.15.1", LockfileFormatPnpmV6},
+		{nodejsPnpm, "9.0.0", LockfileFormatPnpmV9},
+		{nodejsBun, "1.1.0", LockfileFormatBunBinary},
+		{nodejsBun, "1.2.0", LockfileFormatBunText},
+	}
+	for _, tt := range tests {
+		got, err := tt.pm.LockfileFormatForVersion(tt.version)
+		assert.NilError(t, err, "%v %v", tt.pm.Name, tt.version)
+		assert.Equal(t, got, tt.want, "%v %v", tt.pm.Name, tt.version)
+	}
+
+	_, err := nodejsNpm.LockfileFormatForVersion("latest")
+	assert.ErrorContains(t, err, `could not parse npm version "latest"`)
+}
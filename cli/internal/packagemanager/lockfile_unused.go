@@ -0,0 +1,95 @@
+package packagemanager
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// lockfileEntryGraph is implemented by lockfiles which record the
+// dependencies of every package they contain. Entries are identified by the
+// keys the lockfile uses for them, such as `node_modules/react` for npm.
+type lockfileEntryGraph interface {
+	// packageEntries returns every entry for a package installed from
+	// outside the repository. Workspaces and links to them are excluded.
+	packageEntries() []string
+
+	// resolveWorkspaceDependency returns the entry installed for the
+	// dependency `name: specifier` declared by the workspace at dir, or "" if
+	// there is none, as for a dependency on another workspace.
+	resolveWorkspaceDependency(dir string, name string, specifier string) string
+
+	// entryDependencies returns the entries installed for the dependencies of
+	// the package entry.
+	entryDependencies(entry string) []string
+}
+
+// FindUnusedLockfileEntries returns, sorted, the lockfile entries at rootpath
+// which no workspace manifest, including the root package.json, depends on
+// directly or transitively. These are usually left behind by a removed
+// dependency. Entries are named as the lockfile keys them, e.g.
+// `node_modules/react` for npm, `/react@18.2.0` for pnpm, or the resolution
+// `react@npm:18.2.0` for berry.
+func (pm PackageManager) FindUnusedLockfileEntries(rootpath fs.AbsolutePath) ([]string, error) {
+	lockfile, err := pm.ReadLockfile(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	graph, ok := lockfile.(lockfileEntryGraph)
+	if !ok {
+		return nil, fmt.Errorf("finding unused entries of %v is not supported for %v", pm.Lockfile, pm.Name)
+	}
+
+	manifests, err := pm.GetWorkspaces(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	manifests = append(manifests, rootpath.Join("package.json").ToStringDuringMigration())
+
+	var pending []string
+	for _, manifest := range manifests {
+		relativeManifestPath, err := filepath.Rel(rootpath.ToStringDuringMigration(), manifest)
+		if err != nil {
+			return nil, err
+		}
+		dependencies, err := GetWorkspaceDependencies(manifest)
+		if err != nil {
+			return nil, err
+		}
+		dir := WorkspaceDir(relativeManifestPath)
+		for _, section := range []map[string]string{
+			dependencies.Dependencies,
+			dependencies.DevDependencies,
+			dependencies.OptionalDependencies,
+			dependencies.PeerDependencies,
+		} {
+			for name, specifier := range section {
+				if entry := graph.resolveWorkspaceDependency(dir, name, specifier); entry != "" {
+					pending = append(pending, entry)
+				}
+			}
+		}
+	}
+
+	used := make(map[string]bool)
+	for len(pending) > 0 {
+		entry := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if used[entry] {
+			continue
+		}
+		used[entry] = true
+		pending = append(pending, graph.entryDependencies(entry)...)
+	}
+
+	unused := []string{}
+	for _, entry := range graph.packageEntries() {
+		if !used[entry] {
+			unused = append(unused, entry)
+		}
+	}
+	sort.Strings(unused)
+	return unused, nil
+}
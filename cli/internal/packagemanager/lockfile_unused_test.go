@@ -0,0 +1,215 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// Each lockfile below installs react, its loose-envify and js-tokens
+// dependencies, and turbo for the manifests of unusedFixture, along with
+// left-pad, which nothing depends on any more.
+
+const unusedNpmLockfile = `{
+  "name": "root",
+  "lockfileVersion": 3,
+  "requires": true,
+  "packages": {
+    "": {"name": "root", "workspaces": ["apps/*", "packages/*"], "devDependencies": {"turbo": "latest"}},
+    "apps/web": {"name": "web", "dependencies": {"react": "^18.2.0", "ui": "*"}},
+    "packages/ui": {"name": "ui"},
+    "node_modules/left-pad": {"version": "1.3.0"},
+    "node_modules/loose-envify": {"version": "1.4.0", "dependencies": {"js-tokens": "^4.0.0"}},
+    "node_modules/loose-envify/node_modules/js-tokens": {"version": "4.0.0"},
+    "node_modules/js-tokens": {"version": "3.0.2"},
+    "node_modules/turbo": {"version": "1.10.0"},
+    "node_modules/ui": {"resolved": "packages/ui", "link": true},
+    "apps/web/node_modules/react": {"version": "18.2.0", "dependencies": {"loose-envify": "^1.1.0"}}
+  }
+}`
+
+const unusedPnpmLockfile = `lockfileVersion: '6.0'
+
+importers:
+
+  .:
+    devDependencies:
+      turbo:
+        specifier: latest
+        version: 1.10.0
+
+  apps/web:
+    dependencies:
+      react:
+        specifier: ^18.2.0
+        version: 18.2.0
+      ui:
+        specifier: workspace:*
+        version: link:../../packages/ui
+
+  packages/ui: {}
+
+packages:
+
+  /js-tokens@4.0.0:
+    resolution: {integrity: sha512-a}
+
+  /left-pad@1.3.0:
+    resolution: {integrity: sha512-b}
+
+  /loose-envify@1.4.0:
+    resolution: {integrity: sha512-c}
+    dependencies:
+      js-tokens: 4.0.0
+
+  /react@18.2.0:
+    resolution: {integrity: sha512-d}
+    dependencies:
+      loose-envify: 1.4.0
+
+  /turbo@1.10.0:
+    resolution: {integrity: sha512-e}
+`
+
+const unusedBerryLockfile = `__metadata:
+  version: 6
+  cacheKey: 8
+
+"js-tokens@npm:^3.0.0 || ^4.0.0":
+  version: 4.0.0
+  resolution: "js-tokens@npm:4.0.0"
+
+"left-pad@npm:^1.3.0":
+  version: 1.3.0
+  resolution: "left-pad@npm:1.3.0"
+
+"loose-envify@npm:^1.1.0":
+  version: 1.4.0
+  resolution: "loose-envify@npm:1.4.0"
+  dependencies:
+    js-tokens: ^3.0.0 || ^4.0.0
+
+"react@npm:^18.2.0":
+  version: 18.2.0
+  resolution: "react@npm:18.2.0"
+  dependencies:
+    loose-envify: ^1.1.0
+
+"root@workspace:.":
+  version: 0.0.0-use.local
+  resolution: "root@workspace:."
+  dependencies:
+    turbo: latest
+
+"turbo@npm:latest":
+  version: 1.10.0
+  resolution: "turbo@npm:1.10.0"
+
+"ui@*, ui@workspace:packages/ui":
+  version: 0.0.0-use.local
+  resolution: "ui@workspace:packages/ui"
+
+"web@workspace:apps/web":
+  version: 0.0.0-use.local
+  resolution: "web@workspace:apps/web"
+  dependencies:
+    react: ^18.2.0
+    ui: "*"
+`
+
+const unusedBunLockfile = `{
+  "lockfileVersion": 1,
+  "workspaces": {
+    "": {
+      "name": "root",
+      "devDependencies": {
+        "turbo": "latest",
+      },
+    },
+    "apps/web": {
+      "name": "web",
+      "dependencies": {
+        "react": "^18.2.0",
+        "ui": "*",
+      },
+    },
+    "packages/ui": {
+      "name": "ui",
+    },
+  },
+  "packages": {
+    "js-tokens": ["js-tokens@3.0.2", "", {}, "sha512-a"],
+    "left-pad": ["left-pad@1.3.0", "", {}, "sha512-b"],
+    "loose-envify": ["loose-envify@1.4.0", "", { "dependencies": { "js-tokens": "^4.0.0" } }, "sha512-c"],
+    "loose-envify/js-tokens": ["js-tokens@4.0.0", "", {}, "sha512-d"],
+    "turbo": ["turbo@1.10.0", "", {}, "sha512-e"],
+    "ui": ["ui@workspace:packages/ui"],
+    "web/react": ["react@18.2.0", "", { "dependencies": { "loose-envify": "^1.1.0" } }, "sha512-f"],
+  }
+}
+`
+
+func unusedFixture(lockfile string, contents string) map[string]string {
+	return map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"], "devDependencies": {"turbo": "latest"}}`,
+		"pnpm-workspace.yaml":      "packages:\n  - apps/*\n  - packages/*\n",
+		"apps/web/package.json":    `{"name": "web", "dependencies": {"react": "^18.2.0", "ui": "*"}}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+		lockfile:                   contents,
+	}
+}
+
+func TestFindUnusedLockfileEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		pm       PackageManager
+		file     string
+		lockfile string
+		want     []string
+	}{
+		{
+			name:     "npm",
+			pm:       nodejsNpm,
+			lockfile: unusedNpmLockfile,
+			// The hoisted js-tokens is shadowed by the copy nested in loose-envify.
+			want: []string{"node_modules/js-tokens", "node_modules/left-pad"},
+		},
+		{
+			name:     "pnpm",
+			pm:       nodejsPnpm,
+			lockfile: unusedPnpmLockfile,
+			want:     []string{"/left-pad@1.3.0"},
+		},
+		{
+			name:     "berry",
+			pm:       nodejsBerry,
+			lockfile: unusedBerryLockfile,
+			want:     []string{"left-pad@npm:1.3.0"},
+		},
+		{
+			name:     "bun",
+			pm:       nodejsBun,
+			file:     bunTextLockfile,
+			lockfile: unusedBunLockfile,
+			want:     []string{"js-tokens", "left-pad"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.file
+			if file == "" {
+				file = tt.pm.Lockfile
+			}
+			rootPath := setupFixture(t, unusedFixture(file, tt.lockfile))
+			unused, err := tt.pm.FindUnusedLockfileEntries(rootPath)
+			assert.NilError(t, err, "FindUnusedLockfileEntries")
+			assert.DeepEqual(t, unused, tt.want)
+		})
+	}
+}
+
+func TestFindUnusedLockfileEntries_Unsupported(t *testing.T) {
+	rootPath := setupFixture(t, unusedFixture("yarn.lock", "# yarn lockfile v1\n"))
+	_, err := nodejsYarn.FindUnusedLockfileEntries(rootPath)
+	assert.ErrorContains(t, err, "reading yarn.lock is not supported for nodejs-yarn")
+}
@@ -0,0 +1,51 @@
+package packagemanager
+
+import "github.com/vercel/turborepo/cli/internal/fs"
+
+// Manager is the behavior of a package manager, implemented by PackageManager.
+// Code which only needs this behavior can accept a Manager so that tests may
+// substitute a fake for a real repository.
+type Manager interface {
+	// GetWorkspaces returns the list of package.json files for the repository.
+	GetWorkspaces(rootpath fs.AbsolutePath) ([]string, error)
+
+	// GetWorkspacesWithOpts returns the list of package.json files for the
+	// repository, discovered according to opts.
+	GetWorkspacesWithOpts(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]string, error)
+
+	// GetWorkspaceIgnores returns the globs not to search for workspaces.
+	GetWorkspaceIgnores(rootpath fs.AbsolutePath) ([]string, error)
+
+	// GetWorkspacePackages returns the workspaces along with their parsed manifests.
+	GetWorkspacePackages(rootpath fs.AbsolutePath) ([]WorkspacePackage, error)
+
+	// GetWorkspacePackagesWithOpts returns the workspaces along with their
+	// parsed manifests, discovered according to opts.
+	GetWorkspacePackagesWithOpts(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]WorkspacePackage, error)
+
+	// HasWorkspaces reports whether the repository defines workspaces at all.
+	HasWorkspaces(rootpath fs.AbsolutePath, pkg *fs.PackageJSON) (bool, error)
+
+	// GetVersion returns the version of the package manager installed for the project.
+	GetVersion(projectDirectory string) (string, error)
+
+	// LockfilePath returns the location of the lockfile for the project.
+	LockfilePath(projectDirectory fs.AbsolutePath) fs.AbsolutePath
+
+	// ReadLockfile reads and parses the lockfile for the project.
+	ReadLockfile(rootpath fs.AbsolutePath) (Lockfile, error)
+
+	// LockfileCheckCommand returns the command which verifies the lockfile is up to date.
+	LockfileCheckCommand() []string
+
+	// AddCommand returns the command which adds a dependency.
+	AddCommand(pkgName string, dev bool) []string
+
+	// RemoveCommand returns the command which removes a dependency.
+	RemoveCommand(pkgName string) []string
+
+	// RunCommand returns the command which runs a package.json script.
+	RunCommand(script string) []string
+}
+
+var _ Manager = PackageManager{}
@@ -0,0 +1,115 @@
+package packagemanager
+
+import (
+	iofs "io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/doublestar"
+	"github.com/vercel/turborepo/cli/internal/globby"
+)
+
+// WorkspaceMatcher returns the absolute paths of the files under basePath
+// which match any of includes and none of excludes. Patterns are relative to
+// basePath, and excludes apply to entire directories.
+type WorkspaceMatcher func(basePath string, includes []string, excludes []string) ([]string, error)
+
+// GlobbyMatcher is the default WorkspaceMatcher. Wildcards match dotfiles and
+// dot directories.
+var GlobbyMatcher WorkspaceMatcher = globby.GlobFiles
+
+// MinimatchMatcher is a WorkspaceMatcher following minimatch's default
+// behavior, as used by npm and yarn: wildcards, including `**`, do not match
+// path segments beginning with a dot unless the pattern segment itself does.
+func MinimatchMatcher(basePath string, includes []string, excludes []string) ([]string, error) {
+	includePatterns, err := compileScanPatterns(basePath, includes, "")
+	if err != nil {
+		return nil, err
+	}
+	excludePatterns, err := compileScanPatterns(basePath, excludes, "**")
+	if err != nil {
+		return nil, err
+	}
+	splitIncludes := make([][]string, len(includePatterns))
+	for i, pattern := range includePatterns {
+		splitIncludes[i] = strings.Split(pattern, "/")
+	}
+
+	var matches []string
+	err = walkDirRoot(basePath, func(p string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && p != basePath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p == basePath {
+			return nil
+		}
+		rel, err := filepath.Rel(basePath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		excluded, err := matchesAny(excludePatterns, rel)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if excluded {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if excluded {
+			return nil
+		}
+
+		segments := strings.Split(rel, "/")
+		for _, include := range splitIncludes {
+			matched, err := minimatchSegments(include, segments)
+			if err != nil {
+				return err
+			}
+			if matched {
+				matches = append(matches, p)
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// minimatchSegments reports whether the path segments match the pattern
+// segments, without matching dot segments against wildcards.
+func minimatchSegments(pattern []string, segments []string) (bool, error) {
+	if len(pattern) == 0 {
+		return len(segments) == 0, nil
+	}
+	if pattern[0] == "**" {
+		matched, err := minimatchSegments(pattern[1:], segments)
+		if err != nil || matched {
+			return matched, err
+		}
+		if len(segments) == 0 || strings.HasPrefix(segments[0], ".") {
+			return false, nil
+		}
+		return minimatchSegments(pattern, segments[1:])
+	}
+	if len(segments) == 0 {
+		return false, nil
+	}
+	if strings.HasPrefix(segments[0], ".") && !strings.HasPrefix(pattern[0], ".") {
+		return false, nil
+	}
+	matched, err := doublestar.Match(pattern[0], segments[0])
+	if err != nil || !matched {
+		return false, err
+	}
+	return minimatchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,84 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWorkspaceMatchers(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                          `{"name": "root", "workspaces": ["packages/*", "tools/**"]}`,
+		"packages/ui/package.json":              `{"name": "ui"}`,
+		"packages/.hidden/package.json":         `{"name": "hidden"}`,
+		"packages/ui/node_modules/package.json": `{"name": "nested"}`,
+		"tools/cli/package.json":                `{"name": "cli"}`,
+		"tools/.cache/tmp/package.json":         `{"name": "cached"}`,
+		"tools/scripts/lint/package.json":       `{"name": "lint"}`,
+	})
+
+	tests := []struct {
+		name    string
+		matcher WorkspaceMatcher
+		want    []string
+	}{
+		{
+			name:    "default",
+			matcher: nil,
+			want: []string{
+				"packages/.hidden/package.json",
+				"packages/ui/package.json",
+				"tools/.cache/tmp/package.json",
+				"tools/cli/package.json",
+				"tools/scripts/lint/package.json",
+			},
+		},
+		{
+			name:    "globby",
+			matcher: GlobbyMatcher,
+			want: []string{
+				"packages/.hidden/package.json",
+				"packages/ui/package.json",
+				"tools/.cache/tmp/package.json",
+				"tools/cli/package.json",
+				"tools/scripts/lint/package.json",
+			},
+		},
+		{
+			name:    "minimatch",
+			matcher: MinimatchMatcher,
+			want: []string{
+				"packages/ui/package.json",
+				"tools/cli/package.json",
+				"tools/scripts/lint/package.json",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaces, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Matcher: tt.matcher})
+			assert.NilError(t, err, "GetWorkspacesWithOpts")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), tt.want)
+		})
+	}
+}
+
+func Test_minimatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern []string
+		path    []string
+		want    bool
+	}{
+		{pattern: []string{"packages", "*", "package.json"}, path: []string{"packages", "ui", "package.json"}, want: true},
+		{pattern: []string{"packages", "*", "package.json"}, path: []string{"packages", ".ui", "package.json"}, want: false},
+		{pattern: []string{"packages", ".*", "package.json"}, path: []string{"packages", ".ui", "package.json"}, want: true},
+		{pattern: []string{"**", "package.json"}, path: []string{"package.json"}, want: true},
+		{pattern: []string{"**", "package.json"}, path: []string{"a", ".b", "package.json"}, want: false},
+		{pattern: []string{"{apps,packages}", "*", "package.json"}, path: []string{"apps", "web", "package.json"}, want: true},
+	}
+	for _, tt := range tests {
+		got, err := minimatchSegments(tt.pattern, tt.path)
+		assert.NilError(t, err, "minimatchSegments")
+		assert.Equal(t, got, tt.want, "%v against %v", tt.path, tt.pattern)
+	}
+}
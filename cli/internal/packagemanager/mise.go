@@ -0,0 +1,118 @@
+package packagemanager
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// miseConfigFiles are the mise configuration files read for a pinned package
+// manager, in order of precedence.
+var miseConfigFiles = []string{".mise.toml", "mise.toml"}
+
+// readMisePackageManager returns the package manager pinned in the [tools]
+// table of the mise configuration at projectDirectory, e.g. `pnpm = "8.6.0"`.
+// It returns nil if there is no configuration, if it pins no package manager,
+// or if it pins more than one, since which of them installs is then unclear.
+func readMisePackageManager(projectDirectory fs.AbsolutePath) (*PackageManager, error) {
+	for _, file := range miseConfigFiles {
+		configPath := projectDirectory.Join(file)
+		if !configPath.FileExists() {
+			continue
+		}
+		contents, err := configPath.ReadFile()
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", file, err)
+		}
+		tools, err := parseMiseTools(normalizeLineEndings(contents))
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", file, err)
+		}
+
+		var pinned *PackageManager
+		for _, tool := range tools {
+			packageManager := findPackageManager(tool.name, tool.version)
+			if packageManager == nil {
+				continue
+			}
+			if pinned != nil {
+				return nil, nil
+			}
+			pinned = packageManager
+		}
+		return pinned, nil
+	}
+	return nil, nil
+}
+
+// miseTool is a single entry of the mise [tools] table.
+type miseTool struct {
+	name    string
+	version string
+}
+
+// parseMiseTools returns the entries of the [tools] table in contents, in
+// file order. Only the subset of TOML used for tool versions is understood: a
+// version string, an array whose first element is the version, or an inline
+// table with a version key.
+func parseMiseTools(contents []byte) ([]miseTool, error) {
+	var tools []miseTool
+	inTools := false
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inTools = line == "[tools]"
+			continue
+		}
+		if !inTools {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid [tools] entry %q", line)
+		}
+		version, ok := miseToolVersion(strings.TrimSpace(value))
+		if !ok {
+			return nil, fmt.Errorf("invalid version for tool %v: %v", strings.TrimSpace(key), strings.TrimSpace(value))
+		}
+		tools = append(tools, miseTool{name: strings.Trim(strings.TrimSpace(key), `"'`), version: version})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+// miseToolVersion extracts the version from the value of a mise tool entry.
+func miseToolVersion(value string) (string, bool) {
+	switch {
+	case strings.HasPrefix(value, "["):
+		value = strings.TrimSpace(strings.TrimPrefix(value, "["))
+		first, _, _ := strings.Cut(value, ",")
+		return miseToolVersion(strings.TrimSpace(strings.TrimSuffix(first, "]")))
+	case strings.HasPrefix(value, "{"):
+		for _, field := range strings.Split(strings.Trim(value, "{}"), ",") {
+			key, version, found := strings.Cut(field, "=")
+			if found && strings.TrimSpace(key) == "version" {
+				return miseToolVersion(strings.TrimSpace(version))
+			}
+		}
+		return "", false
+	}
+	// A trailing comment follows the closing quote.
+	if len(value) < 2 || (value[0] != '"' && value[0] != '\'') {
+		return "", false
+	}
+	end := strings.IndexByte(value[1:], value[0])
+	if end == -1 {
+		return "", false
+	}
+	return value[1 : end+1], true
+}
@@ -0,0 +1,92 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDetectionStrategies_Mise(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		want       string
+		wantReason DetectionReason
+	}{
+		{
+			name: "mise pins pnpm",
+			files: map[string]string{
+				"package.json": `{"name": "root"}`,
+				".mise.toml":   "[env]\nNODE_ENV = \"development\"\n\n[tools]\nnode = \"20\" # LTS\npnpm = \"8.6.0\"\n",
+			},
+			want:       "nodejs-pnpm",
+			wantReason: ReasonMise,
+		},
+		{
+			name: "mise pins berry with an inline table",
+			files: map[string]string{
+				"package.json": `{"name": "root"}`,
+				"mise.toml":    "[tools]\r\n\"yarn\" = { version = \"3.2.1\" }\r\n",
+			},
+			want:       "nodejs-berry",
+			wantReason: ReasonMise,
+		},
+		{
+			name: "mise pins yarn classic with an array",
+			files: map[string]string{
+				"package.json": `{"name": "root"}`,
+				".mise.toml":   "[tools]\nyarn = ['1.22.19', '3.2.1']\n",
+			},
+			want:       "nodejs-yarn",
+			wantReason: ReasonMise,
+		},
+		{
+			name: "lockfile wins over mise",
+			files: map[string]string{
+				"package.json":      `{"name": "root"}`,
+				"package-lock.json": "{}\n",
+				".mise.toml":        "[tools]\npnpm = \"8.6.0\"\n",
+			},
+			want:       "nodejs-npm",
+			wantReason: ReasonDetected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, tt.files)
+
+			got, reason, err := resolvePackageManager(rootPath, nil, Opts{})
+			assert.NilError(t, err, "resolvePackageManager")
+			assert.Equal(t, got.Name, tt.want)
+			assert.Equal(t, reason, tt.wantReason)
+		})
+	}
+}
+
+func TestReadMisePackageManager(t *testing.T) {
+	t.Run("several package managers are ambiguous", func(t *testing.T) {
+		rootPath := setupFixture(t, map[string]string{
+			".mise.toml": "[tools]\nnpm = \"9.8.1\"\npnpm = \"8.6.0\"\n",
+		})
+		got, err := readMisePackageManager(rootPath)
+		assert.NilError(t, err, "readMisePackageManager")
+		assert.Assert(t, got == nil)
+	})
+
+	t.Run("other tables are ignored", func(t *testing.T) {
+		rootPath := setupFixture(t, map[string]string{
+			".mise.toml": "[tools]\nnode = \"20\"\n\n[plugins]\npnpm = \"https://github.com/jonathanmorley/asdf-pnpm\"\n",
+		})
+		got, err := readMisePackageManager(rootPath)
+		assert.NilError(t, err, "readMisePackageManager")
+		assert.Assert(t, got == nil)
+	})
+
+	t.Run("unquoted version", func(t *testing.T) {
+		rootPath := setupFixture(t, map[string]string{
+			".mise.toml": "[tools]\npnpm = 8\n",
+		})
+		_, err := readMisePackageManager(rootPath)
+		assert.ErrorContains(t, err, ".mise.toml: invalid version for tool pnpm: 8")
+	})
+}
@@ -0,0 +1,44 @@
+package packagemanager
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// ResolveNewWorkspacePath returns where a new workspace named name should be
+// created so that glob, a workspace glob declared at rootpath, matches it.
+// The workspace is placed directly under the glob's leading segments which
+// contain no glob syntax, so `packages/*` and `packages/**` both place `foo`
+// at packages/foo. A scoped name such as `@acme/foo` is placed at the same
+// path as `foo`.
+func ResolveNewWorkspacePath(rootpath fs.AbsolutePath, glob string, name string) (fs.AbsolutePath, error) {
+	if strings.HasPrefix(glob, "!") {
+		return "", fmt.Errorf("invalid workspace glob %q: new workspaces cannot be placed by a negated glob", glob)
+	}
+	cleaned := path.Clean(filepath.ToSlash(glob))
+	if !hasGlobMeta(cleaned) {
+		return "", fmt.Errorf("invalid workspace glob %q: it matches a single workspace and cannot hold new ones", glob)
+	}
+	base := globBase(cleaned)
+	if base == "." {
+		return "", fmt.Errorf("invalid workspace glob %q: it has no directory without wildcards to place new workspaces in", glob)
+	}
+	if base == ".." || strings.HasPrefix(base, "../") || path.IsAbs(base) {
+		return "", fmt.Errorf("invalid workspace glob %q: workspace globs must be within the repository root", glob)
+	}
+
+	dir := name
+	if strings.HasPrefix(name, "@") {
+		if _, unscoped, ok := strings.Cut(name, "/"); ok {
+			dir = unscoped
+		}
+	}
+	if dir == "" || dir == "." || dir == ".." || strings.ContainsAny(dir, `/\`) {
+		return "", fmt.Errorf("invalid workspace name %q", name)
+	}
+	return rootpath.Join(filepath.FromSlash(base), dir), nil
+}
@@ -0,0 +1,44 @@
+package packagemanager
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func TestResolveNewWorkspacePath(t *testing.T) {
+	rootPath := fs.AbsolutePathFromUpstream(filepath.FromSlash("/repo"))
+	tests := []struct {
+		name      string
+		glob      string
+		workspace string
+		want      string
+		wantErr   string
+	}{
+		{name: "single wildcard", glob: "packages/*", workspace: "foo", want: "packages/foo"},
+		{name: "globstar", glob: "packages/**", workspace: "foo", want: "packages/foo"},
+		{name: "nested prefix", glob: "./apps/internal/*/", workspace: "foo", want: "apps/internal/foo"},
+		{name: "partial wildcard", glob: "apps/web-*", workspace: "foo", want: "apps/foo"},
+		{name: "scoped name", glob: "packages/*", workspace: "@acme/foo", want: "packages/foo"},
+		{name: "no concrete prefix", glob: "*", workspace: "foo", wantErr: "no directory without wildcards"},
+		{name: "leading globstar", glob: "**/packages/*", workspace: "foo", wantErr: "no directory without wildcards"},
+		{name: "no wildcard", glob: "packages/ui", workspace: "foo", wantErr: "matches a single workspace"},
+		{name: "negated", glob: "!packages/*", workspace: "foo", wantErr: "negated glob"},
+		{name: "outside root", glob: "../packages/*", workspace: "foo", wantErr: "within the repository root"},
+		{name: "name with separator", glob: "packages/*", workspace: "a/b", wantErr: "invalid workspace name"},
+		{name: "empty name", glob: "packages/*", workspace: "", wantErr: "invalid workspace name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveNewWorkspacePath(rootPath, tt.glob, tt.workspace)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err, "ResolveNewWorkspacePath")
+			assert.Equal(t, got, rootPath.Join(filepath.FromSlash(tt.want)))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package packagemanager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// CheckNodeEngine returns a warning if nodeVersion does not satisfy the
+// `engines.node` range declared by pkg, or nil if it does or no range is
+// declared. It is an error for either to be unparseable.
+func CheckNodeEngine(pkg *fs.PackageJSON, nodeVersion string) (*Warning, error) {
+	nodeRange := strings.TrimSpace(pkg.Engines["node"])
+	if nodeRange == "" {
+		return nil, nil
+	}
+
+	version, err := semver.NewVersion(strings.TrimSpace(nodeVersion))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse node version: %w", err)
+	}
+	constraint, err := semver.NewConstraint(normalizeEngineRange(nodeRange))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse engines.node %q: %w", nodeRange, err)
+	}
+	if constraint.Check(version) {
+		return nil, nil
+	}
+	return &Warning{
+		Subject: "engines.node",
+		Message: fmt.Sprintf("node %v does not satisfy the required range %q", version, nodeRange),
+	}, nil
+}
+
+// normalizeEngineRange rewrites npm's space-separated comparator sets, e.g.
+// `>=14.17.0 <15`, into the comma-separated form semver expects. Hyphen
+// ranges are left as they are.
+func normalizeEngineRange(npmRange string) string {
+	sets := strings.Split(npmRange, "||")
+	for i, set := range sets {
+		if strings.Contains(set, " - ") {
+			sets[i] = strings.TrimSpace(set)
+			continue
+		}
+		sets[i] = strings.Join(strings.Fields(set), ", ")
+	}
+	return strings.Join(sets, " || ")
+}
@@ -0,0 +1,40 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckNodeEngine(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeRange   string
+		nodeVersion string
+		wantWarning bool
+		wantErr     bool
+	}{
+		{name: "no range", nodeRange: "", nodeVersion: "v18.12.0"},
+		{name: "satisfied", nodeRange: ">=14", nodeVersion: "v18.12.0"},
+		{name: "satisfied alternative", nodeRange: "^16 || ^18", nodeVersion: "18.12.0"},
+		{name: "satisfied comparator set", nodeRange: ">=14.17.0 <19", nodeVersion: "v18.12.0"},
+		{name: "satisfied hyphen range", nodeRange: "16.0.0 - 18", nodeVersion: "v17.9.1"},
+		{name: "unsatisfied", nodeRange: ">=18", nodeVersion: "v16.13.0", wantWarning: true},
+		{name: "unsatisfied comparator set", nodeRange: ">=14.17.0 <15", nodeVersion: "v16.13.0", wantWarning: true},
+		{name: "invalid range", nodeRange: "lts", nodeVersion: "v16.13.0", wantErr: true},
+		{name: "invalid version", nodeRange: ">=14", nodeVersion: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := &fs.PackageJSON{Engines: map[string]string{"node": tt.nodeRange}}
+			warning, err := CheckNodeEngine(pkg, tt.nodeVersion)
+			if tt.wantErr {
+				assert.Assert(t, err != nil, "expected an error")
+				return
+			}
+			assert.NilError(t, err, "CheckNodeEngine")
+			assert.Equal(t, warning != nil, tt.wantWarning, "warning: %v", warning)
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package packagemanager
 
 import (
+	"bytes"
 	"fmt"
+	"regexp"
 
 	"github.com/vercel/turborepo/cli/internal/fs"
 )
 
+var npmLockfileVersionRegex = regexp.MustCompile(`"lockfileVersion"\s*:\s*(\d+)`)
+
 var nodejsNpm = PackageManager{
 	Name:       "nodejs-npm",
 	Slug:       "npm",
@@ -13,16 +17,74 @@ var nodejsNpm = PackageManager{
 	Specfile:   "package.json",
 	Lockfile:   "package-lock.json",
 	PackageDir: "node_modules",
+	DocsURL:    "https://docs.npmjs.com/cli/using-npm/workspaces",
+
+	// npm 7 introduced workspaces.
+	MinSupportedVersion: "7.0.0",
+
+	// npm ci refuses to proceed when package-lock.json is out of sync, and
+	// --dry-run stops it from touching node_modules.
+	lockfileCheckArgs: []string{"ci", "--dry-run"},
+
+	ciInstallArgs: []string{"ci"},
+
+	// --no-save applies to the lockfile as well as to package.json.
+	installNoSaveArgs: []string{"install", "--no-save"},
+
+	// `npm bin` was removed in npm 9, but the prefix is stable across versions.
+	globalBinArgs:     []string{"prefix", "-g"},
+	globalBinIsPrefix: true,
+
+	addArgs:    []string{"install"},
+	addDevFlag: "-D",
+
+	removeArgs: []string{"uninstall"},
+
+	// `npm why` is an alias of `npm explain`.
+	whyArgs: []string{"why"},
+
+	parseLockfile: parseNpmLockfile,
+
+	lockfileSignature: func(head []byte) bool {
+		return bytes.HasPrefix(bytes.TrimSpace(head), []byte("{"))
+	},
+
+	// npm writes lockfileVersion right after name and version.
+	lockfileVersion: func(head []byte) (string, bool) {
+		match := npmLockfileVersionRegex.FindSubmatch(head)
+		if match == nil {
+			return "", false
+		}
+		return string(match[1]), true
+	},
+
+	// https://docs.npmjs.com/cli/v8/configuring-npm/package-lock-json#lockfileversion
+	lockfileMinimumVersions: map[string]string{
+		"2": "7.0.0",
+		"3": "7.0.0",
+	},
+
+	lockfileFormats: []versionedLockfileFormat{
+		{since: "0.0.0", format: LockfileFormatNpmV1},
+		{since: "7.0.0", format: LockfileFormatNpmV2},
+		{since: "9.0.0", format: LockfileFormatNpmV3},
+	},
+
+	nodeLinker: Hoisted,
+
+	hasWorkspaces: hasPackageJSONWorkspaces,
+
+	readsWorkspaceField: true,
 
-	getWorkspaceGlobs: func(rootpath fs.AbsolutePath) ([]string, error) {
-		pkg, err := fs.ReadPackageJSON(rootpath.Join("package.json").ToStringDuringMigration())
+	getWorkspaceGlobs: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
+		workspaces, err := readPackageJSONWorkspaces(pm, rootpath)
 		if err != nil {
 			return nil, fmt.Errorf("package.json: %w", err)
 		}
-		if len(pkg.Workspaces) == 0 {
-			return nil, fmt.Errorf("package.json: no workspaces found. Turborepo requires npm workspaces to be defined in the root package.json")
+		if len(workspaces) == 0 {
+			return nil, fmt.Errorf("package.json: no workspaces found. Turborepo requires npm workspaces to be defined in the root package.json. See %v", pm.DocsURL)
 		}
-		return pkg.Workspaces, nil
+		return workspaces, nil
 	},
 
 	getWorkspaceIgnores: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
@@ -31,7 +93,7 @@ var nodejsNpm = PackageManager{
 		// key code: https://github.com/npm/map-workspaces/blob/a46503543982cb35f51cc2d6253d4dcc6bca9b32/lib/index.js#L90-L96
 		// call site: https://github.com/npm/cli/blob/7a858277171813b37d46a032e49db44c8624f78f/lib/workspaces/get-workspaces.js#L14
 		return []string{
-			"**/node_modules/**",
+			"**/" + pm.PackageDir + "/**",
 		}, nil
 	},
 
@@ -41,7 +103,7 @@ var nodejsNpm = PackageManager{
 
 	detect: func(projectDirectory fs.AbsolutePath, packageManager *PackageManager) (bool, error) {
 		specfileExists := projectDirectory.Join(packageManager.Specfile).FileExists()
-		lockfileExists := projectDirectory.Join(packageManager.Lockfile).FileExists()
+		lockfileExists := packageManager.LockfilePath(projectDirectory).FileExists()
 
 		return (specfileExists && lockfileExists), nil
 	},
@@ -0,0 +1,19 @@
+package packagemanager
+
+import "time"
+
+// Observer receives timing information about package manager identification
+// and workspace discovery, e.g. to export metrics. Set it in Opts or
+// WorkspaceOpts; when unset, nothing is measured.
+type Observer interface {
+	// OnDetectStart is called before the package manager is identified.
+	OnDetectStart()
+
+	// OnDetectEnd is called once identification finishes, successfully or not,
+	// with the source which determined the package manager.
+	OnDetectEnd(duration time.Duration, reason DetectionReason)
+
+	// OnWorkspaceScanEnd is called once workspace discovery finishes with the
+	// number of workspaces found.
+	OnWorkspaceScanEnd(count int, duration time.Duration)
+}
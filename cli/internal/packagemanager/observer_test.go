@@ -0,0 +1,48 @@
+package packagemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+type recordingObserver struct {
+	events []string
+	reason DetectionReason
+	count  int
+}
+
+func (o *recordingObserver) OnDetectStart() {
+	o.events = append(o.events, "detect-start")
+}
+
+func (o *recordingObserver) OnDetectEnd(duration time.Duration, reason DetectionReason) {
+	o.events = append(o.events, "detect-end")
+	o.reason = reason
+}
+
+func (o *recordingObserver) OnWorkspaceScanEnd(count int, duration time.Duration) {
+	o.events = append(o.events, "workspace-scan-end")
+	o.count = count
+}
+
+func TestObserver(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"package-lock.json":        "{}",
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+	observer := &recordingObserver{}
+
+	packageManager, err := GetPackageManagerWithOpts(rootPath, &fs.PackageJSON{Name: "root"}, Opts{Observer: observer})
+	assert.NilError(t, err, "GetPackageManagerWithOpts")
+	_, err = packageManager.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Observer: observer})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+
+	assert.DeepEqual(t, observer.events, []string{"detect-start", "detect-end", "workspace-scan-end"})
+	assert.Equal(t, observer.reason, ReasonDetected)
+	assert.Equal(t, observer.count, 2)
+}
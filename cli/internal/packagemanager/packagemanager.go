@@ -5,14 +5,20 @@
 package packagemanager
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/vercel/turborepo/cli/internal/fs"
-	"github.com/vercel/turborepo/cli/internal/globby"
 	"github.com/vercel/turborepo/cli/internal/util"
 )
 
@@ -36,8 +42,99 @@ type PackageManager struct {
 	// The directory in which package assets are stored by the Package Manager.
 	PackageDir string
 
+	// The documentation for configuring workspaces with the Package Manager.
+	DocsURL string
+
+	// The oldest version of the Package Manager that turbo supports, or "" if
+	// every version is supported. See CheckMinimumVersion.
+	MinSupportedVersion string
+
+	// Whether the Package Manager is a single-package fallback, returned when
+	// Opts.AllowSinglePackage is set and no package manager could be identified.
+	SinglePackage bool
+
+	// The arguments used to check that the lockfile is up to date.
+	lockfileCheckArgs []string
+
+	// The arguments used for a deterministic install in CI, which fails rather
+	// than modifying the lockfile.
+	ciInstallArgs []string
+
+	// The arguments used for a local install which leaves the lockfile as it is.
+	installNoSaveArgs []string
+
+	// The arguments which print the directory globally installed binaries are
+	// linked into, or nil if the Package Manager has no global installs.
+	globalBinArgs []string
+
+	// Whether globalBinArgs prints the global prefix, within which binaries
+	// are linked into bin/ except on Windows.
+	globalBinIsPrefix bool
+
+	// The arguments used to explain why a dependency is installed, followed by
+	// the package name unless whyListsAll is set.
+	whyArgs []string
+
+	// Whether whyArgs lists the entire dependency tree, because the Package
+	// Manager cannot explain a single package.
+	whyListsAll bool
+
+	// The arguments used to add a dependency, followed by the package name.
+	addArgs []string
+
+	// The flag which makes an added dependency a devDependency.
+	addDevFlag string
+
+	// The arguments used to remove a dependency, followed by the package name.
+	removeArgs []string
+
+	// Returns the absolute path of a release of the Package Manager pinned
+	// in the repository, or "" if none is pinned. Nil if unsupported.
+	pinnedBinary func(rootpath fs.AbsolutePath) (string, error)
+
+	// Parse the contents of the lockfile, or nil if unsupported.
+	parseLockfile func(contents []byte) (Lockfile, error)
+
+	// Reports whether the start of a non-empty lockfile looks like this
+	// manager's lockfile format, or nil if unchecked.
+	lockfileSignature func(head []byte) bool
+
+	// Returns the format version declared at the start of the lockfile, or
+	// nil if unsupported.
+	lockfileVersion func(head []byte) (string, bool)
+
+	// Read and parse the lockfile, for managers which may keep it somewhere
+	// other than LockfilePath. Takes precedence over parseLockfile.
+	readLockfile func(rootpath fs.AbsolutePath) (Lockfile, error)
+
+	// The minimum Package Manager version able to read each lockfile format version.
+	lockfileMinimumVersions map[string]string
+
+	// The lockfile format written by each range of releases, oldest first.
+	lockfileFormats []versionedLockfileFormat
+
+	// The dependency layout produced when no node linker is configured.
+	nodeLinker NodeLinkerStyle
+
+	// Read the node linker configured in the project directory, returning "" if unset.
+	readNodeLinker func(projectDirectory fs.AbsolutePath) (NodeLinkerStyle, error)
+
+	// Read the hoisting settings configured at rootpath. Unset where
+	// dependencies are always hoisted as far as possible.
+	readHoistConfig func(rootpath fs.AbsolutePath) (*HoistConfig, error)
+
+	// Report whether workspaces are defined, inspecting only the configuration file
+	hasWorkspaces func(rootpath fs.AbsolutePath, pkg *fs.PackageJSON) (bool, error)
+
+	// The package.json field workspace globs are read from, if not "workspaces".
+	workspaceField string
+
+	// Whether workspace globs are read from workspaceField of the root
+	// package.json, rather than from the Package Manager's own configuration.
+	readsWorkspaceField bool
+
 	// Return the list of workspace glob
-	getWorkspaceGlobs func(rootpath fs.AbsolutePath) ([]string, error)
+	getWorkspaceGlobs func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error)
 
 	// Return the list of workspace ignore globs
 	getWorkspaceIgnores func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error)
@@ -54,10 +151,11 @@ var packageManagers = []PackageManager{
 	nodejsBerry,
 	nodejsNpm,
 	nodejsPnpm,
+	nodejsBun,
 }
 
 var (
-	packageManagerPattern = `(npm|pnpm|yarn)@(\d+)\.\d+\.\d+(-.+)?`
+	packageManagerPattern = `(npm|pnpm|yarn|bun)@(\d+)\.\d+\.\d+(-.+)?`
 	packageManagerRegex   = regexp.MustCompile(packageManagerPattern)
 )
 
@@ -71,14 +169,282 @@ func ParsePackageManagerString(packageManager string) (manager string, version s
 	return strings.Split(match, "@")[0], strings.Split(match, "@")[1], nil
 }
 
+// Opts configures how the package manager in use is identified.
+type Opts struct {
+	// AllowSinglePackage returns a single-package fallback instead of an error
+	// when no package manager can be identified, provided the project has a
+	// root package.json. See singlePackageManager for which manager is used.
+	AllowSinglePackage bool
+
+	// Observer, if set, is notified when identification starts and ends.
+	Observer Observer
+
+	// IgnorePackageManagerField identifies the package manager from the
+	// filesystem alone, for when the packageManager field is known to be
+	// stale. The detection cache is bypassed too, since it may record a
+	// result taken from the field.
+	IgnorePackageManagerField bool
+
+	// Strategies are the signals used to identify the package manager,
+	// tried in order of confidence. Defaults to DefaultDetectionStrategies.
+	Strategies []DetectionStrategy
+
+	// Logger, if set, receives a warning when the package manager is detected
+	// from a lockfile which appears to be empty or corrupt.
+	Logger hclog.Logger
+
+	// EnforceMinimumVersion returns an error matching ErrUnsupportedVersion
+	// when the identified package manager's version is older than its
+	// MinSupportedVersion. This may spawn the package manager to read its
+	// version. Single-package fallbacks are not checked.
+	EnforceMinimumVersion bool
+
+	// VersionCache, if set, memoizes the versions read by spawning package
+	// managers, instead of the cache shared by the process.
+	VersionCache *VersionCache
+
+	// PersistCache writes the detected package manager and its version to
+	// .turbo/package-manager.json, so that later invocations skip detection
+	// until its inputs change. Results taken from the environment, including
+	// the user agent, and single-package fallbacks are not persisted, nor is
+	// anything while IgnorePackageManagerField is set. This may spawn the
+	// package manager to read its version; failures are ignored.
+	PersistCache bool
+}
+
+// ErrInvalidRootManifest is matched by the error returned when the root
+// package.json exists but cannot be parsed.
+var ErrInvalidRootManifest = errors.New("invalid root package.json")
+
+// InvalidRootManifestError reports a root package.json which cannot be parsed.
+type InvalidRootManifestError struct {
+	// The absolute path to the package.json.
+	Path string
+
+	// The parse failure.
+	Err error
+}
+
+func (e *InvalidRootManifestError) Error() string {
+	return fmt.Sprintf("%v %v: %v", ErrInvalidRootManifest, e.Path, e.Err)
+}
+
+func (e *InvalidRootManifestError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidRootManifest.
+func (e *InvalidRootManifestError) Is(target error) bool {
+	return target == ErrInvalidRootManifest
+}
+
+// ReadRootManifest reads the package.json at projectDirectory. The error
+// matches ErrInvalidRootManifest if the file exists but is not valid JSON.
+func ReadRootManifest(projectDirectory fs.AbsolutePath) (*fs.PackageJSON, error) {
+	manifestPath := projectDirectory.Join("package.json")
+	contents, err := manifestPath.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("package.json: %w", err)
+	}
+	pkg, err := fs.Parse(contents)
+	if err != nil {
+		return nil, &InvalidRootManifestError{Path: manifestPath.ToStringDuringMigration(), Err: err}
+	}
+	return pkg, nil
+}
+
 // GetPackageManager attempts all methods for identifying the package manager in use.
 func GetPackageManager(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON) (packageManager *PackageManager, err error) {
-	result, _ := readPackageManager(pkg)
-	if result != nil {
-		return result, nil
+	return GetPackageManagerWithOpts(projectDirectory, pkg, Opts{})
+}
+
+// GetPackageManagerForWorkspace returns the package manager of the workspace
+// at workspaceDir, for repositories in which a workspace is a project of its
+// own, e.g. a docs site installed with npm inside a pnpm monorepo. A
+// packageManager declared by the workspace wins, then the workspace's own
+// lockfile, and otherwise the package manager of the repository at rootpath.
+func GetPackageManagerForWorkspace(rootpath, workspaceDir fs.AbsolutePath) (*PackageManager, error) {
+	if workspaceDir != rootpath {
+		manifestPath := workspaceDir.Join("package.json")
+		pkg, err := fs.ReadPackageJSON(manifestPath.ToStringDuringMigration())
+		if err != nil {
+			return nil, fmt.Errorf("parsing %v: %w", manifestPath, err)
+		}
+		packageManager, file, err := readDeclaredPackageManager(workspaceDir, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("%v: invalid \"packageManager\" field: %w", workspaceDir.Join(file), err)
+		}
+		if packageManager != nil {
+			return packageManager, nil
+		}
+		detected, err := DetectAll(workspaceDir)
+		if err != nil {
+			return nil, err
+		}
+		if len(detected) > 0 {
+			return detected[0], nil
+		}
+	}
+	return GetPackageManager(rootpath, nil)
+}
+
+// GetPackageManagerWithOpts attempts all methods for identifying the package
+// manager in use, configured by opts. The TURBO_PACKAGE_MANAGER environment
+// variable, if set, takes precedence over everything else.
+func GetPackageManagerWithOpts(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON, opts Opts) (packageManager *PackageManager, err error) {
+	packageManager, _, err = resolvePackageManager(projectDirectory, pkg, opts)
+	if err != nil || !opts.EnforceMinimumVersion || packageManager.SinglePackage {
+		return packageManager, err
+	}
+	version, err := packageManager.GetVersionWithCache(projectDirectory.ToStringDuringMigration(), opts.VersionCache)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine %v version: %w", packageManager.Command, err)
+	}
+	if err := packageManager.CheckMinimumVersion(version); err != nil {
+		return nil, err
+	}
+	return packageManager, nil
+}
+
+// DetectionReason identifies the source which determined the package manager.
+type DetectionReason string
+
+const (
+	// ReasonEnvironment means the TURBO_PACKAGE_MANAGER environment variable was set.
+	ReasonEnvironment DetectionReason = "environment"
+	// ReasonCache means a fresh .turbo/package-manager.json was found.
+	ReasonCache DetectionReason = "cache"
+	// ReasonPackageManagerField means the root package.json packageManager field was used.
+	ReasonPackageManagerField DetectionReason = "packageManager-field"
+	// ReasonDetected means the package manager was detected from the project directory.
+	ReasonDetected DetectionReason = "detected"
+	// ReasonUserAgent means the npm_config_user_agent variable of the
+	// invoking package manager was used.
+	ReasonUserAgent DetectionReason = "user-agent"
+	// ReasonMise means the package manager pinned in the mise configuration
+	// was used.
+	ReasonMise DetectionReason = "mise"
+	// ReasonSinglePackage means the single-package fallback was used.
+	ReasonSinglePackage DetectionReason = "single-package"
+)
+
+// resolvePackageManager identifies the package manager in use along with the
+// source which determined it.
+func resolvePackageManager(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON, opts Opts) (*PackageManager, DetectionReason, error) {
+	packageManager, reason, err := observeIdentifyPackageManager(projectDirectory, pkg, opts)
+	if err == nil && opts.PersistCache {
+		persistPackageManagerCache(projectDirectory, packageManager, reason, opts)
+	}
+	return packageManager, reason, err
+}
+
+// observeIdentifyPackageManager is identifyPackageManager, reported to
+// opts.Observer if set.
+func observeIdentifyPackageManager(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON, opts Opts) (*PackageManager, DetectionReason, error) {
+	if opts.Observer == nil {
+		return identifyPackageManager(projectDirectory, pkg, opts)
+	}
+	opts.Observer.OnDetectStart()
+	start := time.Now()
+	packageManager, reason, err := identifyPackageManager(projectDirectory, pkg, opts)
+	opts.Observer.OnDetectEnd(time.Since(start), reason)
+	return packageManager, reason, err
+}
+
+// persistPackageManagerCache writes packageManager, identified for reason, to
+// the detection cache unless it may not be persisted. See Opts.PersistCache.
+func persistPackageManagerCache(projectDirectory fs.AbsolutePath, packageManager *PackageManager, reason DetectionReason, opts Opts) {
+	if opts.IgnorePackageManagerField {
+		return
+	}
+	switch reason {
+	case ReasonCache, ReasonEnvironment, ReasonUserAgent, ReasonMise, ReasonSinglePackage:
+		return
+	}
+	if version, err := packageManager.GetVersionWithCache(projectDirectory.ToStringDuringMigration(), opts.VersionCache); err == nil {
+		_ = WritePackageManagerCache(projectDirectory, packageManager, version)
+	}
+}
+
+// identifyPackageManager checks each source of package manager identification
+// in order of precedence. With the default strategies this is the
+// TURBO_PACKAGE_MANAGER variable, the detection cache, the packageManager
+// field, the invoking package manager's user agent when the lockfiles are
+// missing or ambiguous, the lockfiles themselves, and finally the package
+// manager pinned by mise.
+func identifyPackageManager(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON, opts Opts) (*PackageManager, DetectionReason, error) {
+	if fromEnv, err := GetPackageManagerFromEnv(os.Getenv); err != nil || fromEnv != nil {
+		return fromEnv, ReasonEnvironment, err
+	}
+
+	if pkg == nil {
+		rootManifest, err := ReadRootManifest(projectDirectory)
+		if errors.Is(err, ErrInvalidRootManifest) {
+			return nil, "", err
+		}
+		pkg = rootManifest
+	}
+
+	strategies := opts.Strategies
+	if strategies == nil {
+		strategies = DefaultDetectionStrategies()
+	}
+	input := DetectionInput{
+		ProjectDirectory:          projectDirectory,
+		Manifest:                  pkg,
+		IgnorePackageManagerField: opts.IgnorePackageManagerField,
+		Logger:                    opts.Logger,
+	}
+	var strategyErr error
+	var strategyErrReason DetectionReason
+	for _, strategy := range byConfidence(strategies) {
+		packageManager, err := strategy.Detect(input)
+		if err != nil {
+			if strategyErr == nil {
+				strategyErr, strategyErrReason = err, strategy.Reason
+			}
+			continue
+		}
+		if packageManager != nil {
+			return packageManager, strategy.Reason, nil
+		}
+	}
+
+	if opts.AllowSinglePackage && pkg != nil && projectDirectory.Join("package.json").FileExists() {
+		return singlePackageManager(projectDirectory), ReasonSinglePackage, nil
+	}
+	if !opts.IgnorePackageManagerField {
+		if _, file, fieldErr := readDeclaredPackageManager(projectDirectory, pkg); fieldErr != nil {
+			// An unusable packageManager field explains the failure better than
+			// the absence of a lockfile does.
+			return nil, ReasonPackageManagerField, fmt.Errorf("%v: invalid \"packageManager\" field: %w", file, fieldErr)
+		}
+	}
+	if strategyErr != nil {
+		return nil, strategyErrReason, strategyErr
+	}
+	return nil, ReasonDetected, undetectedError(projectDirectory)
+}
+
+// singlePackageManager returns the fallback used for a single-package project
+// whose package manager could not be identified. In order of precedence it is
+// the manager owning the first lockfile found, checked in the order of
+// packageManagers (with yarn.lock attributed to berry or classic by
+// detectYarnVariant), and otherwise npm.
+func singlePackageManager(projectDirectory fs.AbsolutePath) *PackageManager {
+	fallback := nodejsNpm
+	for _, packageManager := range packageManagers {
+		if packageManager.LockfilePath(projectDirectory).FileExists() {
+			fallback = packageManager
+			break
+		}
+	}
+	if fallback.Lockfile == nodejsYarn.Lockfile && detectYarnVariant(projectDirectory) == yarnVariantBerry {
+		fallback = nodejsBerry
 	}
 
-	return detectPackageManager(projectDirectory)
+	fallback.SinglePackage = true
+	return &fallback
 }
 
 // readPackageManager attempts to read the package manager from the package.json.
@@ -89,39 +455,392 @@ func readPackageManager(pkg *fs.PackageJSON) (packageManager *PackageManager, er
 			return nil, err
 		}
 
-		for _, packageManager := range packageManagers {
-			isResponsible, err := packageManager.Matches(manager, version)
-			if isResponsible && (err == nil) {
-				return &packageManager, nil
-			}
+		if packageManager := findPackageManager(manager, version); packageManager != nil {
+			return packageManager, nil
 		}
+	} else if len(pkg.PackageManagers) > 0 {
+		return readAvailablePackageManager(pkg.PackageManagers)
 	}
 
 	return nil, errors.New(util.Sprintf("We did not find a package manager specified in your root package.json. Please set the \"packageManager\" property in your root package.json (${UNDERLINE}https://nodejs.org/api/packages.html#packagemanager)${RESET} or run `npx @turbo/codemod add-package-manager` in the root of your monorepo."))
 }
 
+// readAvailablePackageManager returns the first of the packageManager field
+// entries whose command is available on this system. Entries which cannot be
+// parsed are reported alongside unavailable ones rather than stopping the search.
+func readAvailablePackageManager(entries []string) (*PackageManager, error) {
+	problems := make([]string, len(entries))
+	for i, entry := range entries {
+		manager, version, err := parsePackageManagerEntry(entry)
+		if err != nil {
+			problems[i] = fmt.Sprintf("%v: %v", entry, err)
+			continue
+		}
+		packageManager := findPackageManager(manager, version)
+		if packageManager == nil {
+			problems[i] = fmt.Sprintf("%v: unsupported package manager", entry)
+			continue
+		}
+		if err := packageManager.CheckAvailable(); err != nil {
+			problems[i] = fmt.Sprintf("%v: %v", entry, err)
+			continue
+		}
+		return packageManager, nil
+	}
+
+	return nil, fmt.Errorf("none of the package managers listed in package.json are available:\n  %v", strings.Join(problems, "\n  "))
+}
+
+// packageManagerMajorPattern matches entries of a packageManager array which
+// pin only a major or major.minor version, such as `pnpm@8`.
+var packageManagerMajorPattern = regexp.MustCompile(`^(npm|pnpm|yarn|bun)@(\d+)(\.\d+)?$`)
+
+// parsePackageManagerEntry is ParsePackageManagerString for an entry of a
+// packageManager array, which may also pin only a major or major.minor
+// version. Missing components are taken to be zero.
+func parsePackageManagerEntry(entry string) (manager string, version string, err error) {
+	if match := packageManagerMajorPattern.FindStringSubmatch(entry); match != nil {
+		version = match[2] + match[3] + ".0"
+		if match[3] == "" {
+			version += ".0"
+		}
+		return match[1], version, nil
+	}
+	return ParsePackageManagerString(entry)
+}
+
+// findPackageManager returns the known package manager responsible for the
+// given manager and version tuple, or nil if there is none.
+func findPackageManager(manager string, version string) *PackageManager {
+	for _, packageManager := range packageManagers {
+		isResponsible, err := packageManager.Matches(manager, version)
+		if isResponsible && (err == nil) {
+			return &packageManager
+		}
+	}
+	return nil
+}
+
 // detectPackageManager attempts to detect the package manager by inspecting the project directory state.
 func detectPackageManager(projectDirectory fs.AbsolutePath) (packageManager *PackageManager, err error) {
+	detected, err := DetectAll(projectDirectory)
+	if err != nil {
+		return nil, err
+	}
+	if len(detected) > 0 {
+		return detected[0], nil
+	}
+	return nil, undetectedError(projectDirectory)
+}
+
+// undetectedError explains why no package manager could be identified in the
+// project directory.
+func undetectedError(projectDirectory fs.AbsolutePath) error {
+	if err := findStrayPackageManager(projectDirectory); err != nil {
+		return err
+	}
+
+	if !hasAnyLockfile(projectDirectory) {
+		return errors.New(util.Sprintf("We did not detect an in-use package manager for your project: no lockfile was found and the root package.json does not set the \"packageManager\" property. Set it (${UNDERLINE}https://nodejs.org/api/packages.html#packagemanager)${RESET} so that detection does not depend on the lockfile, e.g. in Docker builds which copy package.json before the lockfile."))
+	}
+
+	return errors.New(util.Sprintf("We did not detect an in-use package manager for your project. Please set the \"packageManager\" property in your root package.json (${UNDERLINE}https://nodejs.org/api/packages.html#packagemanager)${RESET} or run `npx @turbo/codemod add-package-manager` in the root of your monorepo."))
+}
+
+// hasAnyLockfile reports whether the lockfile of any package manager exists in
+// the project directory.
+func hasAnyLockfile(projectDirectory fs.AbsolutePath) bool {
+	for _, packageManager := range packageManagers {
+		if packageManager.LockfilePath(projectDirectory).FileExists() {
+			return true
+		}
+	}
+	return false
+}
+
+// findStrayPackageManager returns an error naming the first workspace
+// manifest which sets the packageManager field, for use when it is missing
+// from the root package.json. Workspaces are discovered with each package
+// manager whose workspace configuration is present.
+func findStrayPackageManager(projectDirectory fs.AbsolutePath) error {
+	for _, packageManager := range packageManagers {
+		hasWorkspaces, err := packageManager.HasWorkspaces(projectDirectory, nil)
+		if err != nil || !hasWorkspaces {
+			continue
+		}
+		manifests, err := packageManager.GetWorkspaces(projectDirectory)
+		if err != nil {
+			continue
+		}
+		sort.Strings(manifests)
+		for _, manifest := range manifests {
+			pkg, err := fs.ReadPackageJSON(manifest)
+			if err != nil || (pkg.PackageManager == "" && len(pkg.PackageManagers) == 0) {
+				continue
+			}
+			rel, err := filepath.Rel(projectDirectory.ToStringDuringMigration(), manifest)
+			if err != nil {
+				rel = manifest
+			}
+			return fmt.Errorf("we did not detect an in-use package manager for your project, but the workspace package.json at %v sets \"packageManager\". Move the \"packageManager\" field to the root package.json", filepath.ToSlash(rel))
+		}
+	}
+	return nil
+}
+
+// DetectAll returns every package manager which appears to be in use in the
+// project directory, in order of precedence.
+func DetectAll(projectDirectory fs.AbsolutePath) ([]*PackageManager, error) {
+	var detected []*PackageManager
 	for _, packageManager := range packageManagers {
+		packageManager := packageManager
 		isResponsible, err := packageManager.detect(projectDirectory, &packageManager)
 		if err != nil {
 			return nil, err
 		}
 		if isResponsible {
-			return &packageManager, nil
+			detected = append(detected, &packageManager)
 		}
 	}
 
-	return nil, errors.New(util.Sprintf("We did not detect an in-use package manager for your project. Please set the \"packageManager\" property in your root package.json (${UNDERLINE}https://nodejs.org/api/packages.html#packagemanager)${RESET} or run `npx @turbo/codemod add-package-manager` in the root of your monorepo."))
+	return detected, nil
+}
+
+// WorkspaceOpts configures workspace discovery.
+type WorkspaceOpts struct {
+	// Recursive also includes the members of any workspace that is itself a
+	// workspace root, e.g. an `apps/` package that declares its own workspaces.
+	Recursive bool
+
+	// Logger, if set, receives a warning for each workspace glob that
+	// ValidateWorkspaceGlobs flags.
+	Logger hclog.Logger
+
+	// Matcher finds the workspace manifests matching the workspace globs.
+	// Defaults to GlobbyMatcher.
+	Matcher WorkspaceMatcher
+
+	// Observer, if set, is notified when workspace discovery ends.
+	Observer Observer
+
+	// WorkspaceField names the package.json field to read workspace globs from,
+	// for package managers which declare workspaces there. Defaults to "workspaces".
+	WorkspaceField string
+
+	// IncludeRoot keeps the root package.json in the results when a workspace
+	// glob such as `.` or `**` matches it. By default the root is never
+	// counted as a workspace member.
+	IncludeRoot bool
+
+	// Cache, if set, is consulted before discovering workspaces and updated
+	// afterwards. See WorkspaceCache for when a cached result is reused.
+	Cache *WorkspaceCache
+
+	// MaxDepth, if positive, limits workspace globs to directories at most
+	// this many levels below the directory declaring them, so that a bare
+	// `**` does not walk an entire large repository. Workspaces nested more
+	// deeply are silently missed, so set it no lower than the deepest
+	// workspace. Zero means unlimited.
+	MaxDepth int
+
+	// SynthesizeNames names each workspace whose manifest has no name after
+	// its directory, prefixed with SyntheticNamePrefix, instead of failing.
+	// Only GetWorkspacePackagesWithOpts reads manifest names.
+	SynthesizeNames bool
+
+	// FollowSymlinks also discovers workspaces whose directory is a symlink
+	// matched by a workspace glob, such as a `packages/` entry linked to a
+	// shared `pool/` of sources, and reports every workspace by the real path
+	// of its package.json. A package linked more than once is reported only
+	// once.
+	FollowSymlinks bool
+
+	// SortByDependencyDepth orders workspaces from those without internal
+	// dependencies to those which depend on the most levels of other
+	// workspaces, as batched by WorkspaceGraph.TopologicalBatches, instead of
+	// in discovery order. Workspaces at the same depth are sorted by name.
+	// This parses every manifest and the lockfile to build the dependency
+	// graph, which costs as much as BuildWorkspaceGraph, and it is an error
+	// for workspaces to depend on one another through a cycle. Manifests
+	// without a name are an error unless SynthesizeNames is set.
+	SortByDependencyDepth bool
+}
+
+// maxWorkspaceNesting caps how many levels of nested workspace roots are
+// followed when discovering workspaces recursively.
+const maxWorkspaceNesting = 8
+
+// lookPath is exec.LookPath, overridable for tests.
+var lookPath = exec.LookPath
+
+// CheckAvailable returns an error if the Package Manager's command cannot be
+// found on this system.
+func (pm PackageManager) CheckAvailable() error {
+	if _, err := lookPath(pm.Command); err != nil {
+		return fmt.Errorf("%v is not available: %w", pm.Command, err)
+	}
+	return nil
 }
 
 // GetWorkspaces returns the list of package.json files for the current repository.
+// Workspaces excluded by a .turboignore file are omitted.
 func (pm PackageManager) GetWorkspaces(rootpath fs.AbsolutePath) ([]string, error) {
-	globs, err := pm.getWorkspaceGlobs(rootpath)
+	return pm.GetWorkspacesWithOpts(rootpath, WorkspaceOpts{})
+}
+
+// GetWorkspacePaths returns the same package.json files as GetWorkspaces, as
+// absolute paths.
+func (pm PackageManager) GetWorkspacePaths(rootpath fs.AbsolutePath) ([]fs.AbsolutePath, error) {
+	workspaces, err := pm.GetWorkspaces(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]fs.AbsolutePath, len(workspaces))
+	for i, workspace := range workspaces {
+		paths[i] = fs.AbsolutePathFromUpstream(filepath.Clean(workspace))
+	}
+	return paths, nil
+}
+
+// GetWorkspacesWithOpts returns the list of package.json files for the current
+// repository, discovered according to opts. If TURBO_WORKSPACES lists the
+// workspace directories, their package.json files are returned instead and
+// the filesystem is not searched.
+func (pm PackageManager) GetWorkspacesWithOpts(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]string, error) {
+	if opts.Observer == nil {
+		return pm.getWorkspaces(rootpath, opts)
+	}
+	start := time.Now()
+	workspaces, err := pm.getWorkspaces(rootpath, opts)
+	opts.Observer.OnWorkspaceScanEnd(len(workspaces), time.Since(start))
+	return workspaces, err
+}
+
+func (pm PackageManager) getWorkspaces(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]string, error) {
+	workspaces, err := pm.lookupWorkspaces(rootpath, opts)
+	if err != nil || !opts.SortByDependencyDepth {
+		return workspaces, err
+	}
+	return pm.sortByDependencyDepth(rootpath, workspaces, opts.SynthesizeNames)
+}
+
+// lookupWorkspaces returns the package.json files of the workspaces at
+// rootpath, from TURBO_WORKSPACES or opts.Cache if possible, in discovery order.
+func (pm PackageManager) lookupWorkspaces(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]string, error) {
+	if manifests, ok, err := workspacesFromEnv(rootpath); ok {
+		return manifests, err
+	}
+	if opts.WorkspaceField != "" {
+		pm.workspaceField = opts.WorkspaceField
+	}
+
+	if opts.Cache == nil {
+		return pm.discoverWorkspaces(rootpath, opts)
+	}
+	key := pm.workspaceCacheKey(rootpath, opts)
+	if cached, ok := opts.Cache.get(key); ok {
+		return cached, nil
+	}
+	workspaces, err := pm.discoverWorkspaces(rootpath, opts)
+	if err != nil {
+		return nil, err
+	}
+	// The globs were read successfully moments ago by discoverWorkspaces.
+	globs, _ := pm.workspaceGlobs(rootpath)
+	opts.Cache.put(key, workspaces, pm.workspaceCachePaths(rootpath, globs, workspaces, opts.Recursive))
+	return workspaces, nil
+}
+
+// discoverWorkspaces returns the package.json files of the workspaces at
+// rootpath, without consulting opts.Cache.
+func (pm PackageManager) discoverWorkspaces(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]string, error) {
+	workspaces, err := pm.globWorkspaces(rootpath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Recursive {
+		seen := make(util.Set)
+		seen.Add(realpathOrSelf(rootpath.Join("package.json").ToStringDuringMigration()))
+		workspaces, err = pm.expandNestedWorkspaces(workspaces, opts, seen, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.FollowSymlinks {
+		workspaces = resolveWorkspaceSymlinks(rootpath, workspaces)
+		if !opts.IncludeRoot {
+			workspaces = withoutRootManifest(rootpath, workspaces)
+		}
+	}
+	return workspaces, nil
+}
+
+// workspaceGlobs returns the workspace globs declared at rootpath, rejecting
+// any which are absolute paths rather than relative to rootpath. Globs come
+// from the package manager's configuration or, only if it defines no
+// workspaces, from the experimentalWorkspaces field of turbo.json.
+func (pm PackageManager) workspaceGlobs(rootpath fs.AbsolutePath) ([]string, error) {
+	globs, err := pm.getWorkspaceGlobs(pm, rootpath)
+	if err != nil {
+		// turbo.json is consulted only when the package manager's own
+		// configuration defines no workspaces at all, never to override it.
+		if hasWorkspaces, hasErr := pm.hasWorkspaces(rootpath, nil); hasErr != nil || hasWorkspaces {
+			return nil, err
+		}
+		turboGlobs, turboErr := readTurboJSONWorkspaces(rootpath)
+		if turboErr != nil {
+			return nil, turboErr
+		}
+		if len(turboGlobs) == 0 {
+			return nil, err
+		}
+		globs = turboGlobs
+	}
+	for _, glob := range globs {
+		if filepath.IsAbs(glob) || path.IsAbs(filepath.ToSlash(glob)) {
+			return nil, fmt.Errorf("invalid workspace glob %q: workspace globs must be relative to the repository root, not absolute paths", glob)
+		}
+	}
+	return globs, nil
+}
+
+// readTurboJSONWorkspaces returns the experimentalWorkspaces globs from
+// turbo.json at rootpath, or nil if there is no turbo.json.
+func readTurboJSONWorkspaces(rootpath fs.AbsolutePath) ([]string, error) {
+	turboJSONPath := rootpath.Join("turbo.json")
+	if !turboJSONPath.FileExists() {
+		return nil, nil
+	}
+	turboJSON, err := fs.ReadTurboJSON(turboJSONPath)
+	if err != nil {
+		return nil, fmt.Errorf("turbo.json: %w", err)
+	}
+	return turboJSON.ExperimentalWorkspaces, nil
+}
+
+// globWorkspaces returns the package.json files matched by the workspace globs
+// declared at rootpath.
+func (pm PackageManager) globWorkspaces(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]string, error) {
+	globs, err := pm.workspaceGlobs(rootpath)
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.Logger != nil {
+		if warning := pm.validateWorkspacesField(rootpath); warning != nil {
+			opts.Logger.Warn(warning.String())
+		}
+		for _, warning := range ValidateWorkspaceGlobs(rootpath, globs) {
+			opts.Logger.Warn(fmt.Sprintf("workspace glob %v", warning))
+		}
+	}
+
+	if opts.MaxDepth > 0 {
+		globs = limitGlobsDepth(globs, opts.MaxDepth)
+	}
+
 	justJsons := make([]string, len(globs))
 	for i, space := range globs {
 		justJsons[i] = filepath.Join(space, "package.json")
@@ -132,12 +851,231 @@ func (pm PackageManager) GetWorkspaces(rootpath fs.AbsolutePath) ([]string, erro
 		return nil, err
 	}
 
-	f, err := globby.GlobFiles(rootpath.ToStringDuringMigration(), justJsons, ignores)
+	matcher := opts.Matcher
+	if matcher == nil {
+		matcher = GlobbyMatcher
+	}
+	f, err := matcher(rootpath.ToStringDuringMigration(), justJsons, ignores)
 	if err != nil {
 		return nil, err
 	}
+	if opts.FollowSymlinks {
+		f, err = withLinkedWorkspaces(rootpath, f, globs, ignores)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	return f, nil
+	includes, err := readWorkspaceIncludes(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	if len(includes) > 0 {
+		f, err = reincludeWorkspaces(rootpath, f, justJsons, includes, matcher)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err = applyTurboIgnores(rootpath, f)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.IncludeRoot {
+		return f, nil
+	}
+	return withoutRootManifest(rootpath, f), nil
+}
+
+// withoutRootManifest returns manifests without the root package.json.
+func withoutRootManifest(rootpath fs.AbsolutePath, manifests []string) []string {
+	rootManifest := rootpath.Join("package.json").ToStringDuringMigration()
+	members := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		if filepath.Clean(manifest) != rootManifest {
+			members = append(members, manifest)
+		}
+	}
+	return members
+}
+
+// limitGlobsDepth rewrites globs so that none matches a directory more than
+// maxDepth path segments deep. Each `**` is expanded into a fixed number of
+// `*` segments, so matchers never walk below maxDepth. Globs whose literal
+// segments alone are deeper than maxDepth are dropped.
+func limitGlobsDepth(globs []string, maxDepth int) []string {
+	var limited []string
+	seen := make(util.Set)
+	for _, glob := range globs {
+		cleaned := path.Clean(filepath.ToSlash(glob))
+		if cleaned == "." {
+			limited = append(limited, glob)
+			continue
+		}
+		for _, segments := range expandGlobstars(strings.Split(cleaned, "/"), maxDepth) {
+			expanded := strings.Join(segments, "/")
+			if expanded == "" {
+				expanded = "."
+			}
+			if !seen.Includes(expanded) {
+				seen.Add(expanded)
+				limited = append(limited, expanded)
+			}
+		}
+	}
+	return limited
+}
+
+// expandGlobstars returns every way of replacing each `**` in segments with
+// zero or more `*` segments such that the result has at most budget segments.
+func expandGlobstars(segments []string, budget int) [][]string {
+	if len(segments) == 0 {
+		return [][]string{{}}
+	}
+	var expansions [][]string
+	if segments[0] != "**" {
+		if budget == 0 {
+			return nil
+		}
+		for _, rest := range expandGlobstars(segments[1:], budget-1) {
+			expansions = append(expansions, append([]string{segments[0]}, rest...))
+		}
+		return expansions
+	}
+	for n := 0; n <= budget; n++ {
+		stars := make([]string, n)
+		for i := range stars {
+			stars[i] = "*"
+		}
+		for _, rest := range expandGlobstars(segments[1:], budget-n) {
+			expansions = append(expansions, append(append([]string{}, stars...), rest...))
+		}
+	}
+	return expansions
+}
+
+// expandNestedWorkspaces returns workspaces along with the members of every
+// workspace that is itself a workspace root. Members whose workspace
+// configuration cannot be read are treated as leaves.
+func (pm PackageManager) expandNestedWorkspaces(workspaces []string, opts WorkspaceOpts, seen util.Set, depth int) ([]string, error) {
+	var result []string
+	for _, workspace := range workspaces {
+		realpath := realpathOrSelf(workspace)
+		if seen.Includes(realpath) {
+			continue
+		}
+		seen.Add(realpath)
+		result = append(result, workspace)
+
+		members, err := pm.globWorkspaces(fs.AbsolutePathFromUpstream(filepath.Dir(workspace)), opts)
+		if err != nil {
+			continue
+		}
+		if depth >= maxWorkspaceNesting {
+			return nil, fmt.Errorf("%v: workspaces are nested more than %v levels deep", workspace, maxWorkspaceNesting)
+		}
+		nested, err := pm.expandNestedWorkspaces(members, opts, seen, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, nested...)
+	}
+
+	return result, nil
+}
+
+// realpathOrSelf resolves symlinks in path, returning path unchanged if it
+// cannot be resolved.
+func realpathOrSelf(path string) string {
+	if realpath, err := filepath.EvalSymlinks(path); err == nil {
+		return realpath
+	}
+	return path
+}
+
+// HasWorkspaces reports whether the repository defines workspaces at all,
+// without globbing for them. pkg is the parsed root package.json; if nil it
+// is read from rootpath when needed.
+func (pm PackageManager) HasWorkspaces(rootpath fs.AbsolutePath, pkg *fs.PackageJSON) (bool, error) {
+	return pm.hasWorkspaces(rootpath, pkg)
+}
+
+// IsSinglePackageRepo reports whether rootpath holds a single package rather
+// than a monorepo: there is a root package.json, but no package manager's
+// workspace configuration is present and turbo.json declares no
+// experimentalWorkspaces. pkg is the root package.json, or nil to read it.
+func IsSinglePackageRepo(rootpath fs.AbsolutePath, pkg *fs.PackageJSON) (bool, error) {
+	if pkg == nil {
+		if !rootpath.Join("package.json").FileExists() {
+			return false, nil
+		}
+		rootManifest, err := ReadRootManifest(rootpath)
+		if err != nil {
+			return false, err
+		}
+		pkg = rootManifest
+	}
+
+	for _, packageManager := range packageManagers {
+		hasWorkspaces, err := packageManager.HasWorkspaces(rootpath, pkg)
+		if err != nil {
+			return false, err
+		}
+		if hasWorkspaces {
+			return false, nil
+		}
+	}
+
+	turboWorkspaces, err := readTurboJSONWorkspaces(rootpath)
+	if err != nil {
+		return false, err
+	}
+	return len(turboWorkspaces) == 0, nil
+}
+
+// hasPackageJSONWorkspaces reports whether the root package.json declares any
+// workspaces, for managers which define them there.
+func hasPackageJSONWorkspaces(rootpath fs.AbsolutePath, pkg *fs.PackageJSON) (bool, error) {
+	if pkg == nil {
+		var err error
+		pkg, err = fs.ReadPackageJSON(rootpath.Join("package.json").ToStringDuringMigration())
+		if err != nil {
+			return false, fmt.Errorf("package.json: %w", err)
+		}
+	}
+	return len(pkg.Workspaces) > 0, nil
+}
+
+// readPackageJSONWorkspaces returns the workspace globs declared in the root
+// package.json, read from pm.workspaceField when set.
+func readPackageJSONWorkspaces(pm PackageManager, rootpath fs.AbsolutePath) (fs.Workspaces, error) {
+	packageJSONPath := rootpath.Join("package.json")
+	if pm.workspaceField == "" || pm.workspaceField == "workspaces" {
+		pkg, err := fs.ReadPackageJSON(packageJSONPath.ToStringDuringMigration())
+		if err != nil {
+			return nil, err
+		}
+		return pkg.Workspaces, nil
+	}
+
+	contents, err := packageJSONPath.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &fields); err != nil {
+		return nil, err
+	}
+	raw, ok := fields[pm.workspaceField]
+	if !ok {
+		return nil, nil
+	}
+	var workspaces fs.Workspaces
+	if err := json.Unmarshal(raw, &workspaces); err != nil {
+		return nil, fmt.Errorf("%v: %w", pm.workspaceField, err)
+	}
+	return workspaces, nil
 }
 
 // GetWorkspaceIgnores returns an array of globs not to search for workspaces.
@@ -1,7 +1,11 @@
 package packagemanager
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"sort"
@@ -82,6 +86,20 @@ func TestParsePackageManagerString(t *testing.T) {
 			wantVersion:    "111.0.1",
 			wantErr:        false,
 		},
+		{
+			name:           "supports bun",
+			packageManager: "bun@1.0.7",
+			wantManager:    "bun",
+			wantVersion:    "1.0.7",
+			wantErr:        false,
+		},
+		{
+			name:           "supports bun canary",
+			packageManager: "bun@1.0.7-canary.20240101",
+			wantManager:    "bun",
+			wantVersion:    "1.0.7-canary.20240101",
+			wantErr:        false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -138,6 +156,13 @@ func TestGetPackageManager(t *testing.T) {
 			want:             "nodejs-berry",
 			wantErr:          false,
 		},
+		{
+			name:             "finds bun from a canary package manager string",
+			projectDirectory: cwd,
+			pkg:              &fs.PackageJSON{PackageManager: "bun@1.0.7-canary.20240101"},
+			want:             "nodejs-bun",
+			wantErr:          false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -153,6 +178,61 @@ func TestGetPackageManager(t *testing.T) {
 	}
 }
 
+func TestGetPackageManagerWithOpts_AllowSinglePackage(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "defaults to npm without a lockfile",
+			files: map[string]string{"package.json": `{"name": "app"}`},
+			want:  "nodejs-npm",
+		},
+		{
+			// Berry with the pnp linker is unsupported, so detection fails.
+			name: "uses the manager owning an existing lockfile",
+			files: map[string]string{
+				"package.json":                  `{"name": "app"}`,
+				"yarn.lock":                     "",
+				".yarnrc.yml":                   "nodeLinker: pnp\n",
+				".yarn/releases/yarn-3.2.1.cjs": "",
+			},
+			want: "nodejs-berry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, tt.files)
+			pkg := &fs.PackageJSON{Name: "app"}
+
+			_, err := GetPackageManager(rootPath, pkg)
+			assert.Assert(t, err != nil, "GetPackageManager should fail without AllowSinglePackage")
+
+			got, err := GetPackageManagerWithOpts(rootPath, pkg, Opts{AllowSinglePackage: true})
+			assert.NilError(t, err, "GetPackageManagerWithOpts")
+			assert.Equal(t, got.Name, tt.want)
+			assert.Assert(t, got.SinglePackage)
+		})
+	}
+}
+
+func TestGetPackageManagerWithOpts_IgnorePackageManagerField(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":   `{"name": "root", "packageManager": "npm@8.19.2"}`,
+		"pnpm-lock.yaml": "lockfileVersion: 5.4\n",
+	})
+	pkg := &fs.PackageJSON{Name: "root", PackageManager: "npm@8.19.2"}
+
+	got, err := GetPackageManager(rootPath, pkg)
+	assert.NilError(t, err, "GetPackageManager")
+	assert.Equal(t, got.Name, "nodejs-npm")
+
+	got, err = GetPackageManagerWithOpts(rootPath, pkg, Opts{IgnorePackageManagerField: true})
+	assert.NilError(t, err, "GetPackageManagerWithOpts")
+	assert.Equal(t, got.Name, "nodejs-pnpm")
+}
+
 func Test_readPackageManager(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -199,6 +279,88 @@ func Test_readPackageManager(t *testing.T) {
 	}
 }
 
+func Test_readPackageManager_Array(t *testing.T) {
+	defer func(original func(string) (string, error)) { lookPath = original }(lookPath)
+	lookPath = func(file string) (string, error) {
+		if file == "npm" {
+			return "/usr/bin/npm", nil
+		}
+		return "", exec.ErrNotFound
+	}
+
+	gotPackageManager, err := readPackageManager(&fs.PackageJSON{PackageManagers: []string{"pnpm@8.6.0", "npm@9.0.0"}})
+	assert.NilError(t, err, "readPackageManager")
+	assert.Equal(t, gotPackageManager.Name, "nodejs-npm")
+
+	_, err = readPackageManager(&fs.PackageJSON{PackageManagers: []string{"pnpm@8.6.0", "yarn@3.2.1"}})
+	assert.ErrorContains(t, err, "pnpm@8.6.0: pnpm is not available")
+	assert.ErrorContains(t, err, "yarn@3.2.1: yarn is not available")
+}
+
+func Test_readPackageManager_ArrayMajorOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		available string
+		want      string
+	}{
+		{name: "first available", available: "pnpm", want: "nodejs-pnpm"},
+		{name: "later available", available: "npm", want: "nodejs-npm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(original func(string) (string, error)) { lookPath = original }(lookPath)
+			lookPath = func(file string) (string, error) {
+				if file == tt.available {
+					return "/usr/bin/" + file, nil
+				}
+				return "", exec.ErrNotFound
+			}
+
+			gotPackageManager, err := readPackageManager(&fs.PackageJSON{PackageManagers: []string{"pnpm@8", "npm@9"}})
+			assert.NilError(t, err, "readPackageManager")
+			assert.Equal(t, gotPackageManager.Name, tt.want)
+		})
+	}
+}
+
+func Test_readPackageManager_ArrayUnparseable(t *testing.T) {
+	defer func(original func(string) (string, error)) { lookPath = original }(lookPath)
+	lookPath = func(file string) (string, error) {
+		if file == "npm" {
+			return "/usr/bin/npm", nil
+		}
+		return "", exec.ErrNotFound
+	}
+
+	gotPackageManager, err := readPackageManager(&fs.PackageJSON{PackageManagers: []string{"pnpm@latest", "npm@9.0.0"}})
+	assert.NilError(t, err, "readPackageManager")
+	assert.Equal(t, gotPackageManager.Name, "nodejs-npm")
+
+	_, err = readPackageManager(&fs.PackageJSON{PackageManagers: []string{"pnpm@latest", "yarn@3.2.1"}})
+	assert.ErrorContains(t, err, "pnpm@latest: We could not parse packageManager field")
+	assert.ErrorContains(t, err, "yarn@3.2.1: yarn is not available")
+}
+
+func TestDetectAll(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":      `{"name": "root"}`,
+		"package-lock.json": "{}",
+		"pnpm-lock.yaml":    "lockfileVersion: 5.4\n",
+	})
+
+	detected, err := DetectAll(rootPath)
+	assert.NilError(t, err, "DetectAll")
+	names := make([]string, len(detected))
+	for i, packageManager := range detected {
+		names[i] = packageManager.Name
+	}
+	assert.DeepEqual(t, names, []string{"nodejs-npm", "nodejs-pnpm"})
+
+	first, err := detectPackageManager(rootPath)
+	assert.NilError(t, err, "detectPackageManager")
+	assert.Equal(t, first.Name, "nodejs-npm")
+}
+
 func Test_GetWorkspaces(t *testing.T) {
 	type test struct {
 		name     string
@@ -217,6 +379,7 @@ func Test_GetWorkspaces(t *testing.T) {
 		"nodejs-berry": repoRoot.Join("../../../examples/basic"),
 		"nodejs-yarn":  repoRoot.Join("../../../examples/basic"),
 		"nodejs-pnpm":  repoRoot.Join("../../../examples/with-pnpm"),
+		"nodejs-bun":   repoRoot.Join("../../../examples/basic"),
 	}
 
 	want := map[string][]string{
@@ -241,6 +404,13 @@ func Test_GetWorkspaces(t *testing.T) {
 			filepath.ToSlash(filepath.Join(cwd, "../../../examples/basic/packages/tsconfig/package.json")),
 			filepath.ToSlash(filepath.Join(cwd, "../../../examples/basic/packages/ui/package.json")),
 		},
+		"nodejs-bun": {
+			filepath.ToSlash(filepath.Join(cwd, "../../../examples/basic/apps/docs/package.json")),
+			filepath.ToSlash(filepath.Join(cwd, "../../../examples/basic/apps/web/package.json")),
+			filepath.ToSlash(filepath.Join(cwd, "../../../examples/basic/packages/eslint-config-custom/package.json")),
+			filepath.ToSlash(filepath.Join(cwd, "../../../examples/basic/packages/tsconfig/package.json")),
+			filepath.ToSlash(filepath.Join(cwd, "../../../examples/basic/packages/ui/package.json")),
+		},
 		"nodejs-pnpm": {
 			filepath.ToSlash(filepath.Join(cwd, "../../../examples/with-pnpm/apps/docs/package.json")),
 			filepath.ToSlash(filepath.Join(cwd, "../../../examples/with-pnpm/apps/web/package.json")),
@@ -298,6 +468,7 @@ func Test_GetWorkspaceIgnores(t *testing.T) {
 		"nodejs-berry": {"**/node_modules", "**/.git", "**/.yarn"},
 		"nodejs-yarn":  {"apps/*/node_modules/**", "packages/*/node_modules/**"},
 		"nodejs-pnpm":  {"**/node_modules/**", "**/bower_components/**"},
+		"nodejs-bun":   {"**/node_modules/**"},
 	}
 
 	tests := make([]test, len(packageManagers))
@@ -330,3 +501,636 @@ func Test_GetWorkspaceIgnores(t *testing.T) {
 		})
 	}
 }
+
+// setupFixture writes files, keyed by slash-separated paths relative to the
+// fixture root, into a temporary directory and returns its path.
+func TestMain(m *testing.M) {
+	// Detection reads variables which are set when the tests themselves are
+	// run by a package manager.
+	os.Unsetenv("npm_config_user_agent")
+	os.Unsetenv("COREPACK_ROOT")
+	os.Unsetenv("TURBO_WORKSPACES")
+	os.Exit(m.Run())
+}
+
+func setupFixture(t *testing.T, files map[string]string) fs.AbsolutePath {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	assert.NilError(t, err, "EvalSymlinks")
+	rootPath := fs.AbsolutePathFromUpstream(root)
+	for name, contents := range files {
+		filePath := rootPath.Join(filepath.FromSlash(name))
+		assert.NilError(t, filePath.EnsureDir(), "EnsureDir")
+		assert.NilError(t, filePath.WriteFile([]byte(contents), 0644), "WriteFile")
+	}
+	return rootPath
+}
+
+// relativeWorkspaces converts workspace manifest paths to sorted,
+// slash-separated paths relative to rootPath.
+func relativeWorkspaces(t *testing.T, rootPath fs.AbsolutePath, workspaces []string) []string {
+	t.Helper()
+	relative := make([]string, len(workspaces))
+	for i, workspace := range workspaces {
+		rel, err := filepath.Rel(rootPath.ToStringDuringMigration(), workspace)
+		assert.NilError(t, err, "Rel")
+		relative[i] = filepath.ToSlash(rel)
+	}
+	sort.Strings(relative)
+	return relative
+}
+
+func Test_GetWorkspacesWithOpts_Recursive(t *testing.T) {
+	// Directory layout:
+	// <rootPath>/
+	//   package.json           (workspaces: apps, packages/*)
+	//   apps/
+	//     package.json         (workspaces: *)
+	//     web/package.json
+	//     docs/package.json
+	//   packages/
+	//     ui/package.json
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "meta", "workspaces": ["apps", "packages/*"]}`,
+		"apps/package.json":        `{"name": "apps", "workspaces": ["*"]}`,
+		"apps/web/package.json":    `{"name": "web"}`,
+		"apps/docs/package.json":   `{"name": "docs"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	flat, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, flat), []string{
+		"apps/package.json",
+		"packages/ui/package.json",
+	})
+
+	nested, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Recursive: true})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, nested), []string{
+		"apps/docs/package.json",
+		"apps/package.json",
+		"apps/web/package.json",
+		"packages/ui/package.json",
+	})
+}
+
+func Test_GetWorkspacesWithOpts_RecursiveCycle(t *testing.T) {
+	// apps/loop is a symlink back to the repository root, which would recurse
+	// forever without cycle detection.
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":          `{"name": "meta", "workspaces": ["apps/*"]}`,
+		"apps/web/package.json": `{"name": "web"}`,
+	})
+	assert.NilError(t, rootPath.Join("apps", "loop").Symlink(rootPath.ToStringDuringMigration()), "Symlink")
+
+	nested, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Recursive: true})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, nested), []string{
+		"apps/web/package.json",
+	})
+}
+
+func Test_GetWorkspacesWithOpts_RecursiveTooDeep(t *testing.T) {
+	// nestedRoots returns a fixture in which l/package.json, l/l/package.json,
+	// and so on are each a workspace root with the next as its only
+	// workspace, levels deep, ending in a plain workspace.
+	nestedRoots := func(levels int) map[string]string {
+		files := map[string]string{"package.json": `{"name": "meta", "workspaces": ["l"]}`}
+		dir := "l"
+		for level := 1; level < levels; level++ {
+			files[dir+"/package.json"] = fmt.Sprintf(`{"name": "level-%v", "workspaces": ["l"]}`, level)
+			dir += "/l"
+		}
+		files[dir+"/package.json"] = `{"name": "leaf"}`
+		return files
+	}
+
+	nested, err := nodejsNpm.GetWorkspacesWithOpts(setupFixture(t, nestedRoots(maxWorkspaceNesting)), WorkspaceOpts{Recursive: true})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, len(nested), maxWorkspaceNesting)
+
+	_, err = nodejsNpm.GetWorkspacesWithOpts(setupFixture(t, nestedRoots(maxWorkspaceNesting+1)), WorkspaceOpts{Recursive: true})
+	assert.ErrorContains(t, err, fmt.Sprintf("workspaces are nested more than %v levels deep", maxWorkspaceNesting))
+}
+
+func Test_GetWorkspaces_AbsoluteGlob(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+	absoluteGlob := filepath.ToSlash(rootPath.Join("packages", "*").ToStringDuringMigration())
+	packageJSON := fmt.Sprintf(`{"name": "root", "workspaces": [%q]}`, absoluteGlob)
+	assert.NilError(t, rootPath.Join("package.json").WriteFile([]byte(packageJSON), 0644), "WriteFile")
+
+	_, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.ErrorContains(t, err, fmt.Sprintf("invalid workspace glob %q", absoluteGlob))
+	_, err = nodejsNpm.GetWorkspacesFast(rootPath)
+	assert.ErrorContains(t, err, fmt.Sprintf("invalid workspace glob %q", absoluteGlob))
+}
+
+func Test_GetWorkspaces_GitSubmodules(t *testing.T) {
+	// Directory layout, where packages/sub is a git submodule:
+	// <rootPath>/
+	//   .git/modules/sub/    (the submodule's git directory)
+	//   packages/
+	//     local/package.json
+	//     sub/
+	//       .git             (a file pointing at .git/modules/sub)
+	//       package.json
+	//       node_modules/dep/package.json
+	files := map[string]string{
+		"package.json":                               `{"name": "root", "workspaces": ["packages/*"]}`,
+		"pnpm-workspace.yaml":                        "packages:\n  - packages/*\n",
+		".git/HEAD":                                  "ref: refs/heads/main\n",
+		".git/modules/sub/HEAD":                      "ref: refs/heads/main\n",
+		"packages/local/package.json":                `{"name": "local"}`,
+		"packages/sub/.git":                          "gitdir: ../../.git/modules/sub\n",
+		"packages/sub/package.json":                  `{"name": "sub"}`,
+		"packages/sub/node_modules/dep/package.json": `{"name": "dep"}`,
+	}
+	rootPath := setupFixture(t, files)
+	want := []string{
+		"packages/local/package.json",
+		"packages/sub/package.json",
+	}
+
+	for _, packageManager := range packageManagers {
+		t.Run(packageManager.Name, func(t *testing.T) {
+			workspaces, err := packageManager.GetWorkspaces(rootPath)
+			assert.NilError(t, err, "GetWorkspaces")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), want)
+
+			workspaces, err = packageManager.GetWorkspacesFast(rootPath)
+			assert.NilError(t, err, "GetWorkspacesFast")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), want)
+		})
+	}
+}
+
+func TestHasWorkspaces(t *testing.T) {
+	tests := []struct {
+		name  string
+		pm    PackageManager
+		files map[string]string
+		want  bool
+	}{
+		{
+			name:  "npm single package",
+			pm:    nodejsNpm,
+			files: map[string]string{"package.json": `{"name": "app"}`},
+			want:  false,
+		},
+		{
+			name:  "npm workspaces matching nothing",
+			pm:    nodejsNpm,
+			files: map[string]string{"package.json": `{"name": "root", "workspaces": ["packages/*"]}`},
+			want:  true,
+		},
+		{
+			name:  "yarn workspaces object form",
+			pm:    nodejsYarn,
+			files: map[string]string{"package.json": `{"name": "root", "workspaces": {"packages": ["packages/*"]}}`},
+			want:  true,
+		},
+		{
+			name:  "berry single package",
+			pm:    nodejsBerry,
+			files: map[string]string{"package.json": `{"name": "app"}`},
+			want:  false,
+		},
+		{
+			name:  "pnpm single package",
+			pm:    nodejsPnpm,
+			files: map[string]string{"package.json": `{"name": "app", "workspaces": ["packages/*"]}`},
+			want:  false,
+		},
+		{
+			name: "pnpm workspace file",
+			pm:   nodejsPnpm,
+			files: map[string]string{
+				"package.json":        `{"name": "root"}`,
+				"pnpm-workspace.yaml": "packages:\n  - packages/*\n",
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, tt.files)
+			got, err := tt.pm.HasWorkspaces(rootPath, nil)
+			assert.NilError(t, err, "HasWorkspaces")
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestIsSinglePackageRepo(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  bool
+	}{
+		{
+			name:  "single package",
+			files: map[string]string{"package.json": `{"name": "app"}`, "package-lock.json": "{}"},
+			want:  true,
+		},
+		{
+			name:  "package.json workspaces",
+			files: map[string]string{"package.json": `{"name": "root", "workspaces": ["packages/*"]}`},
+			want:  false,
+		},
+		{
+			name: "pnpm workspace file",
+			files: map[string]string{
+				"package.json":        `{"name": "root"}`,
+				"pnpm-workspace.yaml": "packages:\n  - packages/*\n",
+			},
+			want: false,
+		},
+		{
+			name: "turbo.json experimentalWorkspaces",
+			files: map[string]string{
+				"package.json": `{"name": "root"}`,
+				"turbo.json":   `{"experimentalWorkspaces": ["packages/*"]}`,
+			},
+			want: false,
+		},
+		{
+			name:  "no root package.json",
+			files: map[string]string{"README.md": ""},
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, tt.files)
+			got, err := IsSinglePackageRepo(rootPath, nil)
+			assert.NilError(t, err, "IsSinglePackageRepo")
+			assert.Equal(t, got, tt.want)
+		})
+	}
+
+	rootPath := setupFixture(t, map[string]string{"package.json": `{"name": `})
+	_, err := IsSinglePackageRepo(rootPath, nil)
+	assert.ErrorIs(t, err, ErrInvalidRootManifest)
+}
+
+func Test_GetWorkspacesWithOpts_WorkspaceField(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["legacy/*"], "workspaces2": {"packages": ["apps/*", "packages/*"]}}`,
+		"legacy/old/package.json":  `{"name": "old"}`,
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	for _, pm := range []PackageManager{nodejsNpm, nodejsYarn, nodejsBerry} {
+		t.Run(pm.Name, func(t *testing.T) {
+			workspaces, err := pm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{WorkspaceField: "workspaces2"})
+			assert.NilError(t, err, "GetWorkspacesWithOpts")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+				"apps/web/package.json",
+				"packages/ui/package.json",
+			})
+
+			workspaces, err = pm.GetWorkspaces(rootPath)
+			assert.NilError(t, err, "GetWorkspaces")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{"legacy/old/package.json"})
+
+			_, err = pm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{WorkspaceField: "missing"})
+			assert.ErrorContains(t, err, "no workspaces found")
+		})
+	}
+}
+
+func Test_GetWorkspaces_ExcludesPackageDir(t *testing.T) {
+	for _, packageDir := range []string{"node_modules", "vendor_modules"} {
+		for _, packageManager := range packageManagers {
+			pm := packageManager
+			pm.PackageDir = packageDir
+			t.Run(packageDir+"/"+pm.Name, func(t *testing.T) {
+				rootPath := setupFixture(t, map[string]string{
+					"package.json":             `{"name": "root", "workspaces": ["packages/**"]}`,
+					"pnpm-workspace.yaml":      "packages:\n  - packages/**\n",
+					"packages/ui/package.json": `{"name": "ui"}`,
+					"packages/ui/" + packageDir + "/react/package.json":   `{"name": "react"}`,
+					"packages/ui/" + packageDir + "/.pnpm/a/package.json": `{"name": "a"}`,
+				})
+
+				workspaces, err := pm.GetWorkspaces(rootPath)
+				assert.NilError(t, err, "GetWorkspaces")
+				assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{"packages/ui/package.json"})
+			})
+		}
+	}
+}
+
+func Test_GetWorkspaces_MaxDepth(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":            `{"name": "root", "workspaces": ["**"]}`,
+		"a/package.json":          `{"name": "a"}`,
+		"x/y/z/package.json":      `{"name": "z"}`,
+		"packages/b/package.json": `{"name": "b"}`,
+	})
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		want     []string
+	}{
+		{name: "unlimited", want: []string{"a/package.json", "packages/b/package.json", "x/y/z/package.json"}},
+		{name: "depth 2", maxDepth: 2, want: []string{"a/package.json", "packages/b/package.json"}},
+		{name: "depth 1", maxDepth: 1, want: []string{"a/package.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaces, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{MaxDepth: tt.maxDepth})
+			assert.NilError(t, err, "GetWorkspacesWithOpts")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), tt.want)
+		})
+	}
+}
+
+func Test_limitGlobsDepth(t *testing.T) {
+	assert.DeepEqual(t, limitGlobsDepth([]string{"packages/**", "apps/*", "a/b/c/*", "."}, 2), []string{"packages", "packages/*", "apps/*", "."})
+	assert.DeepEqual(t, limitGlobsDepth([]string{"**/x"}, 2), []string{"x", "*/x"})
+}
+
+func Test_SymlinkedRoot(t *testing.T) {
+	realRoot := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["packages/*"]}`,
+		"pnpm-workspace.yaml":      "packages:\n  - packages/*\n",
+		"pnpm-lock.yaml":           "lockfileVersion: 5.4\n",
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+	link := filepath.Join(t.TempDir(), "worktree")
+	if err := os.Symlink(realRoot.ToStringDuringMigration(), link); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+	rootPath := fs.AbsolutePathFromUpstream(link)
+
+	packageManager, err := GetPackageManager(rootPath, nil)
+	assert.NilError(t, err, "GetPackageManager")
+	assert.Equal(t, packageManager.Name, "nodejs-pnpm")
+
+	want := []string{rootPath.Join("packages", "ui", "package.json").ToStringDuringMigration()}
+	for _, pm := range packageManagers {
+		t.Run(pm.Name, func(t *testing.T) {
+			workspaces, err := pm.GetWorkspaces(rootPath)
+			assert.NilError(t, err, "GetWorkspaces")
+			assert.DeepEqual(t, workspaces, want)
+
+			workspaces, err = pm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Matcher: MinimatchMatcher})
+			assert.NilError(t, err, "GetWorkspacesWithOpts")
+			assert.DeepEqual(t, workspaces, want)
+
+			workspaces, err = pm.GetWorkspacesFast(rootPath)
+			assert.NilError(t, err, "GetWorkspacesFast")
+			assert.DeepEqual(t, workspaces, want)
+		})
+	}
+}
+
+func Test_GetWorkspacePaths(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "./packages/*/"]}`,
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	workspaces, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	paths, err := nodejsNpm.GetWorkspacePaths(rootPath)
+	assert.NilError(t, err, "GetWorkspacePaths")
+	assert.Equal(t, len(paths), len(workspaces))
+
+	var manifests []string
+	for _, p := range paths {
+		s := p.ToStringDuringMigration()
+		assert.Assert(t, filepath.IsAbs(s), "%v is not absolute", s)
+		assert.Equal(t, s, filepath.Clean(s))
+		assert.Equal(t, filepath.Base(s), "package.json")
+		contained, err := rootPath.ContainsPath(p)
+		assert.NilError(t, err, "ContainsPath")
+		assert.Assert(t, contained, "%v is outside %v", s, rootPath)
+		manifests = append(manifests, s)
+	}
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, manifests), []string{"apps/web/package.json", "packages/ui/package.json"})
+}
+
+func Test_GetWorkspaces_ExcludesRoot(t *testing.T) {
+	tests := []struct {
+		name        string
+		globs       string
+		matchesRoot bool
+	}{
+		{name: "star", globs: `["*"]`},
+		{name: "dot", globs: `[".", "packages/*"]`, matchesRoot: true},
+		{name: "globstar", globs: `["**"]`, matchesRoot: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{
+				"package.json":             `{"name": "root", "workspaces": ` + tt.globs + `}`,
+				"docs/package.json":        `{"name": "docs"}`,
+				"packages/ui/package.json": `{"name": "ui"}`,
+			})
+
+			workspaces, err := nodejsNpm.GetWorkspaces(rootPath)
+			assert.NilError(t, err, "GetWorkspaces")
+			for _, workspace := range relativeWorkspaces(t, rootPath, workspaces) {
+				assert.Assert(t, workspace != "package.json", "root manifest counted as a workspace")
+			}
+
+			fast, err := nodejsNpm.GetWorkspacesFast(rootPath)
+			assert.NilError(t, err, "GetWorkspacesFast")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, fast), relativeWorkspaces(t, rootPath, workspaces))
+
+			withRoot, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{IncludeRoot: true})
+			assert.NilError(t, err, "GetWorkspacesWithOpts")
+			if tt.matchesRoot {
+				assert.Equal(t, len(withRoot), len(workspaces)+1)
+			} else {
+				assert.Equal(t, len(withRoot), len(workspaces))
+			}
+		})
+	}
+}
+
+func TestGetPackageManager_InvalidRootManifest(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":      `{"name": "root", "workspaces": ["packages/*"],}`,
+		"package-lock.json": "{}",
+	})
+
+	_, err := GetPackageManager(rootPath, nil)
+	assert.Assert(t, errors.Is(err, ErrInvalidRootManifest), "expected ErrInvalidRootManifest, got %v", err)
+	assert.ErrorContains(t, err, rootPath.Join("package.json").ToStringDuringMigration())
+
+	var syntaxErr *json.SyntaxError
+	assert.Assert(t, errors.As(err, &syntaxErr), "expected the JSON parse failure to be wrapped")
+
+	_, err = ReadRootManifest(rootPath)
+	assert.Assert(t, errors.Is(err, ErrInvalidRootManifest), "expected ErrInvalidRootManifest, got %v", err)
+}
+
+func TestGetPackageManager_StrayPackageManager(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui", "packageManager": "npm@8.1.0"}`,
+	})
+
+	_, err := GetPackageManager(rootPath, &fs.PackageJSON{Name: "root"})
+	assert.ErrorContains(t, err, "packages/ui/package.json sets \"packageManager\"")
+	assert.ErrorContains(t, err, "Move the \"packageManager\" field to the root package.json")
+}
+
+func TestGetPackageManager_NoLockfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		pkg        *fs.PackageJSON
+		want       string
+		wantErrMsg string
+	}{
+		{
+			name: "uses the packageManager field",
+			pkg:  &fs.PackageJSON{Name: "root", PackageManager: "pnpm@7.9.0"},
+			want: "nodejs-pnpm",
+		},
+		{
+			name:       "suggests the packageManager field",
+			pkg:        &fs.PackageJSON{Name: "root"},
+			wantErrMsg: "no lockfile was found and the root package.json does not set the \"packageManager\" property",
+		},
+		{
+			name:       "reports an invalid packageManager field",
+			pkg:        &fs.PackageJSON{Name: "root", PackageManager: "pnpm@latest"},
+			wantErrMsg: "package.json: invalid \"packageManager\" field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, map[string]string{
+				"package.json": `{"name": "root"}`,
+			})
+
+			got, err := GetPackageManager(rootPath, tt.pkg)
+			if tt.wantErrMsg != "" {
+				assert.ErrorContains(t, err, tt.wantErrMsg)
+				return
+			}
+			assert.NilError(t, err, "GetPackageManager")
+			assert.Equal(t, got.Name, tt.want)
+		})
+	}
+}
+
+func Test_GetWorkspaces_DocsURL(t *testing.T) {
+	want := map[string]string{
+		"nodejs-npm":   "https://docs.npmjs.com/cli/using-npm/workspaces",
+		"nodejs-berry": "https://yarnpkg.com/features/workspaces",
+		"nodejs-yarn":  "https://classic.yarnpkg.com/en/docs/workspaces",
+		"nodejs-pnpm":  "https://pnpm.io/workspaces",
+		"nodejs-bun":   "https://bun.sh/docs/install/workspaces",
+	}
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":        `{"name": "root"}`,
+		"pnpm-workspace.yaml": "packages: []\n",
+	})
+
+	for _, pm := range packageManagers {
+		t.Run(pm.Name, func(t *testing.T) {
+			assert.Equal(t, pm.DocsURL, want[pm.Name])
+			_, err := pm.GetWorkspaces(rootPath)
+			assert.ErrorContains(t, err, "See "+want[pm.Name])
+		})
+	}
+}
+
+func Test_GetWorkspaces_TurboJSONWorkspaces(t *testing.T) {
+	files := map[string]string{
+		"package.json":             `{"name": "root"}`,
+		"turbo.json":               `{"experimentalWorkspaces": ["apps/*", "packages/*"], "pipeline": {}}`,
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+		"legacy/old/package.json":  `{"name": "old"}`,
+	}
+
+	for _, pm := range []PackageManager{nodejsNpm, nodejsPnpm} {
+		t.Run(pm.Name, func(t *testing.T) {
+			rootPath := setupFixture(t, files)
+			workspaces, err := pm.GetWorkspaces(rootPath)
+			assert.NilError(t, err, "GetWorkspaces")
+			assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+				"apps/web/package.json",
+				"packages/ui/package.json",
+			})
+		})
+	}
+
+	t.Run("package manager configuration takes precedence", func(t *testing.T) {
+		rootPath := setupFixture(t, files)
+		assert.NilError(t, rootPath.Join("package.json").WriteFile([]byte(`{"name": "root", "workspaces": ["legacy/*"]}`), 0644), "WriteFile")
+		workspaces, err := nodejsNpm.GetWorkspaces(rootPath)
+		assert.NilError(t, err, "GetWorkspaces")
+		assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{"legacy/old/package.json"})
+	})
+}
+
+func TestGetPackageManagerForWorkspace(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                `{"name": "root", "packageManager": "pnpm@8.6.0", "workspaces": ["apps/*", "packages/*"]}`,
+		"pnpm-workspace.yaml":         "packages:\n  - apps/*\n  - packages/*\n",
+		"pnpm-lock.yaml":              "lockfileVersion: '6.0'\n",
+		"apps/docs/package.json":      `{"name": "docs"}`,
+		"apps/docs/package-lock.json": "{\"lockfileVersion\": 3}\n",
+		"apps/mobile/package.json":    `{"name": "mobile", "packageManager": "yarn@1.22.19"}`,
+		"packages/ui/package.json":    `{"name": "ui"}`,
+		"apps/broken/package.json":    `{"name": "broken", "packageManager": "yarn"}`,
+	})
+
+	tests := []struct {
+		workspaceDir string
+		want         string
+	}{
+		{"apps/docs", "nodejs-npm"},
+		{"apps/mobile", "nodejs-yarn"},
+		{"packages/ui", "nodejs-pnpm"},
+		{".", "nodejs-pnpm"},
+	}
+	for _, tt := range tests {
+		got, err := GetPackageManagerForWorkspace(rootPath, rootPath.Join(tt.workspaceDir))
+		assert.NilError(t, err, "GetPackageManagerForWorkspace(%v)", tt.workspaceDir)
+		assert.Equal(t, got.Name, tt.want, tt.workspaceDir)
+	}
+
+	_, err := GetPackageManagerForWorkspace(rootPath, rootPath.Join("apps", "broken"))
+	assert.ErrorContains(t, err, `invalid "packageManager" field`)
+}
+
+func Test_GetWorkspacesWithOpts_FollowSymlinks(t *testing.T) {
+	// packages/ui and packages/ui-legacy both link to the same source in pool/.
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":              `{"name": "root", "workspaces": ["packages/*"]}`,
+		"pool/ui/package.json":      `{"name": "ui"}`,
+		"packages/web/package.json": `{"name": "web"}`,
+	})
+	pool := rootPath.Join("pool", "ui").ToStringDuringMigration()
+	assert.NilError(t, rootPath.Join("packages", "ui").Symlink(pool), "Symlink")
+	assert.NilError(t, rootPath.Join("packages", "ui-legacy").Symlink(pool), "Symlink")
+
+	workspaces, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	// Symlinked directories are not searched by default.
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+		"packages/web/package.json",
+	})
+
+	workspaces, err = nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{FollowSymlinks: true})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+		"packages/web/package.json",
+		"pool/ui/package.json",
+	})
+}
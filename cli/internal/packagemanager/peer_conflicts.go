@@ -0,0 +1,100 @@
+package packagemanager
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// PeerConflict is a package on which workspaces declare peer dependency ranges
+// that no single version satisfies.
+type PeerConflict struct {
+	// The name of the peer dependency.
+	Package string
+
+	// The declared ranges keyed by workspace name.
+	Ranges map[string]string
+}
+
+// FindPeerDependencyConflicts returns, sorted by package name, each package
+// on which the workspaces at rootpath declare peer dependency ranges that no
+// single version satisfies. Specifiers which are not semver ranges, such as
+// `workspace:*` or a git URL, cannot be compared and are disregarded.
+func (pm PackageManager) FindPeerDependencyConflicts(rootpath fs.AbsolutePath) ([]PeerConflict, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make(map[string]map[string]string)
+	for _, workspace := range workspaces {
+		dependencies, err := GetWorkspaceDependencies(workspace.ManifestPath.ToStringDuringMigration())
+		if err != nil {
+			return nil, err
+		}
+		for name, specifier := range dependencies.PeerDependencies {
+			if _, err := semver.NewConstraint(normalizeEngineRange(specifier)); err != nil {
+				continue
+			}
+			if ranges[name] == nil {
+				ranges[name] = make(map[string]string)
+			}
+			ranges[name][workspace.Name] = specifier
+		}
+	}
+
+	conflicts := []PeerConflict{}
+	for name, declared := range ranges {
+		if len(declared) > 1 && !rangesIntersect(declared) {
+			conflicts = append(conflicts, PeerConflict{Package: name, Ranges: declared})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Package < conflicts[j].Package
+	})
+	return conflicts, nil
+}
+
+var rangeVersionPattern = regexp.MustCompile(`\d+(\.(\d+|[xX*])){0,2}`)
+
+// rangesIntersect reports whether a single version satisfies every npm range
+// in ranges. semver cannot intersect constraints, so candidate versions are
+// tested instead: any intersection of ranges has a lowest version which is
+// either one of the versions they mention or the patch release after one.
+func rangesIntersect(ranges map[string]string) bool {
+	var constraints []*semver.Constraints
+	candidates := []*semver.Version{semver.MustParse("0.0.0")}
+	for _, npmRange := range ranges {
+		constraint, err := semver.NewConstraint(normalizeEngineRange(npmRange))
+		if err != nil {
+			continue
+		}
+		constraints = append(constraints, constraint)
+		for _, mentioned := range rangeVersionPattern.FindAllString(npmRange, -1) {
+			mentioned = strings.NewReplacer("x", "0", "X", "0", "*", "0").Replace(mentioned)
+			version, err := semver.NewVersion(mentioned)
+			if err != nil {
+				continue
+			}
+			next := version.IncPatch()
+			candidates = append(candidates, version, &next)
+		}
+	}
+
+	for _, candidate := range candidates {
+		satisfied := true
+		for _, constraint := range constraints {
+			if !constraint.Check(candidate) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
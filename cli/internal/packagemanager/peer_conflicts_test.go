@@ -0,0 +1,52 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFindPeerDependencyConflicts(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root", "workspaces": ["packages/*"]}`,
+		"packages/ui/package.json": `{"name": "ui", "peerDependencies": {
+			"react": "^17.0.0", "react-dom": ">=17.0.0 <19", "typescript": "workspace:*"}}`,
+		"packages/charts/package.json": `{"name": "charts", "peerDependencies": {
+			"react": "^18.2.0", "react-dom": "^18.2.0", "typescript": "^5.0.0"}}`,
+		"packages/forms/package.json": `{"name": "forms", "peerDependencies": {"react": "16.x || 17.x"}}`,
+	})
+
+	conflicts, err := nodejsNpm.FindPeerDependencyConflicts(rootPath)
+	assert.NilError(t, err, "FindPeerDependencyConflicts")
+	assert.DeepEqual(t, conflicts, []PeerConflict{
+		{
+			Package: "react",
+			Ranges:  map[string]string{"ui": "^17.0.0", "charts": "^18.2.0", "forms": "16.x || 17.x"},
+		},
+	})
+}
+
+func Test_rangesIntersect(t *testing.T) {
+	tests := []struct {
+		ranges []string
+		want   bool
+	}{
+		{[]string{"^1.2.0", "^1.5.0"}, true},
+		{[]string{"^1.2.0", "^2.0.0"}, false},
+		{[]string{">1.0.0 <2", ">1.5.0"}, true},
+		{[]string{"<2", "<3.0.0"}, true},
+		{[]string{"~1.2.3", "1.2.x"}, true},
+		{[]string{"~1.2.3", "1.3.x"}, false},
+		{[]string{"1.x || 3.x", "^3.1.0"}, true},
+		{[]string{"*", "^4.0.0"}, true},
+		{[]string{"<=1.0.0", ">=1.0.0"}, true},
+		{[]string{"<1.0.0", ">=1.0.0"}, false},
+	}
+	for _, tt := range tests {
+		ranges := make(map[string]string, len(tt.ranges))
+		for i, npmRange := range tt.ranges {
+			ranges[string(rune('a'+i))] = npmRange
+		}
+		assert.Equal(t, rangesIntersect(ranges), tt.want, "%v", tt.ranges)
+	}
+}
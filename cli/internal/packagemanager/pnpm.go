@@ -1,8 +1,12 @@
 package packagemanager
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/vercel/turborepo/cli/internal/fs"
 	"gopkg.in/yaml.v3"
@@ -12,6 +16,59 @@ import (
 // in pnpm-workspace.yaml
 type PnpmWorkspaces struct {
 	Packages []string `yaml:"packages,omitempty"`
+
+	// The default catalog of shared dependency versions.
+	Catalog map[string]string `yaml:"catalog,omitempty"`
+
+	// Named catalogs of shared dependency versions.
+	Catalogs map[string]map[string]string `yaml:"catalogs,omitempty"`
+}
+
+// pnpmDefaultCatalog is the name of the catalog referenced by a bare `catalog:` specifier.
+const pnpmDefaultCatalog = "default"
+
+// GetPnpmCatalogs returns the catalogs defined in pnpm-workspace.yaml, keyed by
+// name, each mapping dependency names to versions. The top-level `catalog` is
+// returned as the "default" catalog.
+func GetPnpmCatalogs(rootpath fs.AbsolutePath) (map[string]map[string]string, error) {
+	contents, err := rootpath.Join("pnpm-workspace.yaml").ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("pnpm-workspace.yaml: %w", err)
+	}
+	var pnpmWorkspaces PnpmWorkspaces
+	if err := yaml.Unmarshal(normalizeLineEndings(contents), &pnpmWorkspaces); err != nil {
+		return nil, fmt.Errorf("pnpm-workspace.yaml: %w", err)
+	}
+
+	catalogs := make(map[string]map[string]string, len(pnpmWorkspaces.Catalogs)+1)
+	for name, catalog := range pnpmWorkspaces.Catalogs {
+		catalogs[name] = catalog
+	}
+	if len(pnpmWorkspaces.Catalog) > 0 {
+		if _, ok := catalogs[pnpmDefaultCatalog]; ok {
+			return nil, fmt.Errorf("pnpm-workspace.yaml: the default catalog is defined by both catalog and catalogs.%v", pnpmDefaultCatalog)
+		}
+		catalogs[pnpmDefaultCatalog] = pnpmWorkspaces.Catalog
+	}
+	return catalogs, nil
+}
+
+// readPnpmPackageJSONWorkspaces returns the workspace globs declared by the
+// `pnpm.packages` field of the root package.json, or nil if there are none.
+func readPnpmPackageJSONWorkspaces(rootpath fs.AbsolutePath) ([]string, error) {
+	contents, err := rootpath.Join("package.json").ReadFile()
+	if err != nil {
+		return nil, nil
+	}
+	var pkg struct {
+		Pnpm struct {
+			Packages []string `json:"packages"`
+		} `json:"pnpm"`
+	}
+	if err := json.Unmarshal(contents, &pkg); err != nil {
+		return nil, fmt.Errorf("package.json: %w", err)
+	}
+	return pkg.Pnpm.Packages, nil
 }
 
 var nodejsPnpm = PackageManager{
@@ -21,19 +78,94 @@ var nodejsPnpm = PackageManager{
 	Specfile:   "package.json",
 	Lockfile:   "pnpm-lock.yaml",
 	PackageDir: "node_modules",
+	DocsURL:    "https://pnpm.io/workspaces",
+
+	MinSupportedVersion: "6.0.0",
+
+	// pnpm has no check-only mode, so this performs a frozen install that
+	// fails when pnpm-lock.yaml needs updating, avoiding the network if it can.
+	lockfileCheckArgs: []string{"install", "--frozen-lockfile", "--prefer-offline"},
+
+	ciInstallArgs: []string{"install", "--frozen-lockfile"},
+
+	// --no-lockfile neither reads nor writes pnpm-lock.yaml.
+	installNoSaveArgs: []string{"install", "--no-lockfile"},
+
+	globalBinArgs: []string{"bin", "-g"},
+
+	addArgs:    []string{"add"},
+	addDevFlag: "-D",
+
+	removeArgs: []string{"remove"},
+
+	whyArgs: []string{"why"},
 
-	getWorkspaceGlobs: func(rootpath fs.AbsolutePath) ([]string, error) {
+	parseLockfile: parsePnpmLockfile,
+
+	lockfileSignature: func(head []byte) bool {
+		return strings.HasPrefix(firstContentLine(head), "lockfileVersion:")
+	},
+
+	lockfileVersion: func(head []byte) (string, bool) {
+		line := firstContentLine(head)
+		if !strings.HasPrefix(line, "lockfileVersion:") {
+			return "", false
+		}
+		// pnpm 8 and later quote the version, e.g. '6.0'.
+		version := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "lockfileVersion:")), `'"`)
+		return version, version != ""
+	},
+
+	lockfileMinimumVersions: map[string]string{
+		"5.3": "6.0.0",
+		"5.4": "7.0.0",
+		"6.0": "8.0.0",
+		"9.0": "9.0.0",
+	},
+
+	lockfileFormats: []versionedLockfileFormat{
+		{since: "0.0.0", format: LockfileFormatPnpmV5},
+		{since: "8.0.0", format: LockfileFormatPnpmV6},
+		{since: "9.0.0", format: LockfileFormatPnpmV9},
+	},
+
+	nodeLinker:     Isolated,
+	readNodeLinker: readNpmrcNodeLinker,
+
+	readHoistConfig: readNpmrcHoistConfig,
+
+	hasWorkspaces: func(rootpath fs.AbsolutePath, pkg *fs.PackageJSON) (bool, error) {
+		if rootpath.Join("pnpm-workspace.yaml").FileExists() {
+			return true, nil
+		}
+		globs, err := readPnpmPackageJSONWorkspaces(rootpath)
+		return len(globs) > 0, err
+	},
+
+	// pnpm-workspace.yaml takes precedence. Only if it is absent are globs
+	// read from the `pnpm.packages` field of the root package.json.
+	getWorkspaceGlobs: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
 		bytes, err := ioutil.ReadFile(rootpath.Join("pnpm-workspace.yaml").ToStringDuringMigration())
+		if errors.Is(err, os.ErrNotExist) {
+			globs, fieldErr := readPnpmPackageJSONWorkspaces(rootpath)
+			if fieldErr != nil {
+				return nil, fieldErr
+			}
+			if len(globs) > 0 {
+				return globs, nil
+			}
+		}
 		if err != nil {
 			return nil, fmt.Errorf("pnpm-workspace.yaml: %w", err)
 		}
+		// A full YAML parser is required here: workspace files may share glob lists using anchors and aliases.
 		var pnpmWorkspaces PnpmWorkspaces
-		if err := yaml.Unmarshal(bytes, &pnpmWorkspaces); err != nil {
+		if err := yaml.Unmarshal(normalizeLineEndings(bytes), &pnpmWorkspaces); err != nil {
 			return nil, fmt.Errorf("pnpm-workspace.yaml: %w", err)
 		}
 
 		if len(pnpmWorkspaces.Packages) == 0 {
-			return nil, fmt.Errorf("pnpm-workspace.yaml: no packages found. Turborepo requires pnpm workspaces and thus packages to be defined in the root pnpm-workspace.yaml")
+			return nil, fmt.Errorf("pnpm-workspace.yaml: no packages found. Turborepo requires pnpm workspaces and thus packages to be defined in the root pnpm-workspace.yaml. See %v", pm.DocsURL)
 		}
 
 		return pnpmWorkspaces.Packages, nil
@@ -44,8 +176,9 @@ var nodejsPnpm = PackageManager{
 		// function: https://github.com/pnpm/pnpm/blob/d99daa902442e0c8ab945143ebaf5cdc691a91eb/packages/find-packages/src/index.ts#L27
 		// key code: https://github.com/pnpm/pnpm/blob/d99daa902442e0c8ab945143ebaf5cdc691a91eb/packages/find-packages/src/index.ts#L30
 		// call site: https://github.com/pnpm/pnpm/blob/d99daa902442e0c8ab945143ebaf5cdc691a91eb/packages/find-workspace-packages/src/index.ts#L32-L39
+		// pnpm's virtual store, node_modules/.pnpm, is inside the package directory.
 		return []string{
-			"**/node_modules/**",
+			"**/" + pm.PackageDir + "/**",
 			"**/bower_components/**",
 		}, nil
 	},
@@ -54,10 +187,13 @@ var nodejsPnpm = PackageManager{
 		return manager == "pnpm", nil
 	},
 
+	// pnpm workspaces are defined entirely by pnpm-workspace.yaml, so a root package.json is not
+	// required when it is present.
 	detect: func(projectDirectory fs.AbsolutePath, packageManager *PackageManager) (bool, error) {
 		specfileExists := projectDirectory.Join(packageManager.Specfile).FileExists()
-		lockfileExists := projectDirectory.Join(packageManager.Lockfile).FileExists()
+		workspaceFileExists := projectDirectory.Join("pnpm-workspace.yaml").FileExists()
+		lockfileExists := packageManager.LockfilePath(projectDirectory).FileExists()
 
-		return (specfileExists && lockfileExists), nil
+		return ((specfileExists || workspaceFileExists) && lockfileExists), nil
 	},
 }
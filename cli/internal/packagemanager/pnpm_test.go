@@ -0,0 +1,154 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_PnpmWorkspaceAnchors(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root"}`,
+		"pnpm-workspace.yaml": `shared: &shared
+  - apps/*
+  - packages/*
+packages: *shared
+`,
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	globs, err := nodejsPnpm.getWorkspaceGlobs(nodejsPnpm, rootPath)
+	assert.NilError(t, err, "getWorkspaceGlobs")
+	assert.DeepEqual(t, globs, []string{"apps/*", "packages/*"})
+
+	workspaces, err := nodejsPnpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+		"apps/web/package.json",
+		"packages/ui/package.json",
+	})
+}
+
+func Test_PnpmWorkspaceMergedAnchors(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"pnpm-workspace.yaml": `base: &base
+  packages:
+    - packages/*
+<<: *base
+`,
+	})
+
+	globs, err := nodejsPnpm.getWorkspaceGlobs(nodejsPnpm, rootPath)
+	assert.NilError(t, err, "getWorkspaceGlobs")
+	assert.DeepEqual(t, globs, []string{"packages/*"})
+}
+
+func TestGetPnpmCatalogs(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"pnpm-workspace.yaml": `packages:
+  - packages/*
+catalog:
+  react: ^18.2.0
+  react-dom: ^18.2.0
+catalogs:
+  react17:
+    react: ^17.0.2
+    react-dom: ^17.0.2
+  tooling:
+    typescript: 5.3.3
+`,
+	})
+
+	catalogs, err := GetPnpmCatalogs(rootPath)
+	assert.NilError(t, err, "GetPnpmCatalogs")
+	assert.DeepEqual(t, catalogs, map[string]map[string]string{
+		"default": {"react": "^18.2.0", "react-dom": "^18.2.0"},
+		"react17": {"react": "^17.0.2", "react-dom": "^17.0.2"},
+		"tooling": {"typescript": "5.3.3"},
+	})
+}
+
+func TestGetPnpmCatalogs_DuplicateDefault(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"pnpm-workspace.yaml": `catalog:
+  react: ^18.2.0
+catalogs:
+  default:
+    react: ^17.0.2
+`,
+	})
+
+	_, err := GetPnpmCatalogs(rootPath)
+	assert.ErrorContains(t, err, "defined by both catalog and catalogs.default")
+}
+
+func Test_PnpmWorkspacesWithoutRootManifest(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"pnpm-workspace.yaml":                     "packages:\n  - apps/*\n  - packages/*\n",
+		"pnpm-lock.yaml":                          pnpmLockfileV6,
+		"apps/web/package.json":                   `{"name": "web"}`,
+		"packages/ui/package.json":                `{"name": "ui"}`,
+		"packages/ui/node_modules/x/package.json": `{"name": "x"}`,
+	})
+
+	workspaces, err := nodejsPnpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+		"apps/web/package.json",
+		"packages/ui/package.json",
+	})
+
+	hasWorkspaces, err := nodejsPnpm.HasWorkspaces(rootPath, nil)
+	assert.NilError(t, err, "HasWorkspaces")
+	assert.Assert(t, hasWorkspaces)
+
+	packages, err := nodejsPnpm.GetWorkspacePackages(rootPath)
+	assert.NilError(t, err, "GetWorkspacePackages")
+	assert.Equal(t, len(packages), 2)
+
+	detected, err := DetectAll(rootPath)
+	assert.NilError(t, err, "DetectAll")
+	assert.Equal(t, len(detected), 1)
+	assert.Equal(t, detected[0].Name, "nodejs-pnpm")
+
+	packageManager, err := GetPackageManager(rootPath, nil)
+	assert.NilError(t, err, "GetPackageManager")
+	assert.Equal(t, packageManager.Name, "nodejs-pnpm")
+}
+
+func Test_PnpmPackageJSONWorkspaces(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "pnpm": {"packages": ["apps/*", "packages/*"]}}`,
+		"pnpm-lock.yaml":           "lockfileVersion: 5.4\n",
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	hasWorkspaces, err := nodejsPnpm.hasWorkspaces(rootPath, nil)
+	assert.NilError(t, err, "hasWorkspaces")
+	assert.Assert(t, hasWorkspaces)
+
+	workspaces, err := nodejsPnpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+		"apps/web/package.json",
+		"packages/ui/package.json",
+	})
+}
+
+func Test_PnpmWorkspaceYAMLOverridesPackageJSON(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "pnpm": {"packages": ["apps/*"]}}`,
+		"pnpm-workspace.yaml":      "packages:\n  - packages/*\n",
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	globs, err := nodejsPnpm.getWorkspaceGlobs(nodejsPnpm, rootPath)
+	assert.NilError(t, err, "getWorkspaceGlobs")
+	assert.DeepEqual(t, globs, []string{"packages/*"})
+
+	_, err = nodejsPnpm.getWorkspaceGlobs(nodejsPnpm, setupFixture(t, map[string]string{"package.json": `{"name": "root"}`}))
+	assert.ErrorContains(t, err, "pnpm-workspace.yaml: ")
+}
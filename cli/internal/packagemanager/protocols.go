@@ -0,0 +1,123 @@
+package packagemanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// ProtocolIssue is an internal dependency declared without the `workspace:`
+// protocol, which the package manager may resolve from the registry instead
+// of the workspace.
+type ProtocolIssue struct {
+	// The name of the workspace declaring the dependency.
+	Workspace string
+
+	// The manifest section declaring the dependency, e.g. "devDependencies".
+	Section string
+
+	// The name of the internal dependency.
+	Dependency string
+
+	// The version specifier the dependency is declared with.
+	Specifier string
+}
+
+func (i ProtocolIssue) String() string {
+	return fmt.Sprintf("%v: %v %v@%v does not use the workspace: protocol", i.Workspace, i.Section, i.Dependency, i.Specifier)
+}
+
+// AuditWorkspaceProtocols returns an issue for each dependency on another
+// workspace which does not use the `workspace:` protocol. Issues are sorted
+// by workspace directory, then section, then dependency name.
+func (pm PackageManager) AuditWorkspaceProtocols(rootpath fs.AbsolutePath) ([]ProtocolIssue, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	internal := make(map[string]bool, len(workspaces))
+	for _, workspace := range workspaces {
+		internal[workspace.Name] = true
+	}
+
+	issues := []ProtocolIssue{}
+	for _, workspace := range workspaces {
+		sections := []struct {
+			name         string
+			dependencies map[string]string
+		}{
+			{"dependencies", workspace.Manifest.Dependencies},
+			{"devDependencies", workspace.Manifest.DevDependencies},
+			{"optionalDependencies", workspace.Manifest.OptionalDependencies},
+		}
+		for _, section := range sections {
+			var sectionIssues []ProtocolIssue
+			for name, specifier := range section.dependencies {
+				if internal[name] && !isWorkspaceProtocol(specifier) {
+					sectionIssues = append(sectionIssues, ProtocolIssue{
+						Workspace:  workspace.Name,
+						Section:    section.name,
+						Dependency: name,
+						Specifier:  specifier,
+					})
+				}
+			}
+			sort.Slice(sectionIssues, func(i, j int) bool {
+				return sectionIssues[i].Dependency < sectionIssues[j].Dependency
+			})
+			issues = append(issues, sectionIssues...)
+		}
+	}
+	return issues, nil
+}
+
+// ResolveWorkspaceProtocol returns the version range which replaces the
+// `workspace:` specifier spec when a package is published, given the current
+// version of the workspace it refers to:
+//
+//   - `workspace:*` becomes internalVersion exactly.
+//   - `workspace:^` and `workspace:~` become internalVersion with that prefix.
+//   - `workspace:<range>`, such as `workspace:^1.2.3`, becomes the range.
+//   - `workspace:<path>`, as berry allows, becomes internalVersion exactly.
+//   - `workspace:<name>@<variant>`, an alias, becomes `npm:<name>@` followed
+//     by the variant resolved as above.
+func ResolveWorkspaceProtocol(spec string, internalVersion string) (string, error) {
+	if !isWorkspaceProtocol(spec) {
+		return "", fmt.Errorf("%q does not use the workspace: protocol", spec)
+	}
+	target := strings.TrimPrefix(spec, "workspace:")
+
+	// The separator of an alias follows the name, which may itself begin with
+	// the `@` of a scope.
+	if at := strings.LastIndex(target, "@"); at > 0 {
+		resolved, err := resolveWorkspaceTarget(spec, target[at+1:], internalVersion)
+		if err != nil {
+			return "", err
+		}
+		return "npm:" + target[:at] + "@" + resolved, nil
+	}
+	return resolveWorkspaceTarget(spec, target, internalVersion)
+}
+
+// resolveWorkspaceTarget resolves the part of spec following `workspace:` and
+// any alias.
+func resolveWorkspaceTarget(spec string, target string, internalVersion string) (string, error) {
+	var prefix string
+	switch {
+	case target == "*" || target == "" || strings.HasPrefix(target, ".") || strings.HasPrefix(target, "/"):
+		prefix = ""
+	case target == "^" || target == "~":
+		prefix = target
+	case strings.HasPrefix(target, "@"):
+		return "", fmt.Errorf("%q names a package without a version", spec)
+	default:
+		return target, nil
+	}
+	if _, err := semver.NewVersion(internalVersion); err != nil {
+		return "", fmt.Errorf("cannot resolve %q: invalid workspace version %q: %w", spec, internalVersion, err)
+	}
+	return prefix + internalVersion, nil
+}
@@ -0,0 +1,56 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestAuditWorkspaceProtocols(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":        `{"name": "web", "dependencies": {"ui": "workspace:*", "utils": "^1.0.0", "react": "^18.2.0"}, "devDependencies": {"config": "*"}}`,
+		"packages/ui/package.json":     `{"name": "ui", "dependencies": {"utils": "workspace:^"}}`,
+		"packages/utils/package.json":  `{"name": "utils", "version": "1.0.0"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	issues, err := nodejsNpm.AuditWorkspaceProtocols(rootPath)
+	assert.NilError(t, err, "AuditWorkspaceProtocols")
+	assert.DeepEqual(t, issues, []ProtocolIssue{
+		{Workspace: "web", Section: "dependencies", Dependency: "utils", Specifier: "^1.0.0"},
+		{Workspace: "web", Section: "devDependencies", Dependency: "config", Specifier: "*"},
+	})
+}
+
+func TestResolveWorkspaceProtocol(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr string
+	}{
+		{spec: "workspace:*", want: "1.2.3"},
+		{spec: "workspace:^", want: "^1.2.3"},
+		{spec: "workspace:~", want: "~1.2.3"},
+		{spec: "workspace:1.2.3", want: "1.2.3"},
+		{spec: "workspace:^1.0.0", want: "^1.0.0"},
+		{spec: "workspace:>=1.0.0 <2.0.0", want: ">=1.0.0 <2.0.0"},
+		{spec: "workspace:../ui", want: "1.2.3"},
+		{spec: "workspace:ui@*", want: "npm:ui@1.2.3"},
+		{spec: "workspace:@acme/ui@^", want: "npm:@acme/ui@^1.2.3"},
+		{spec: "workspace:@acme/ui", wantErr: "names a package without a version"},
+		{spec: "^1.2.3", wantErr: "does not use the workspace: protocol"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveWorkspaceProtocol(tt.spec, "1.2.3")
+		if tt.wantErr != "" {
+			assert.ErrorContains(t, err, tt.wantErr, tt.spec)
+			continue
+		}
+		assert.NilError(t, err, tt.spec)
+		assert.Equal(t, got, tt.want, tt.spec)
+	}
+
+	_, err := ResolveWorkspaceProtocol("workspace:^", "")
+	assert.ErrorContains(t, err, "invalid workspace version")
+}
@@ -0,0 +1,194 @@
+package packagemanager
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gopkg.in/yaml.v3"
+)
+
+// RegistryConfig describes where a repository's packages are installed from.
+type RegistryConfig struct {
+	// The default registry URL, or "" if unset.
+	Registry string
+
+	// Registry URLs keyed by package scope, e.g. "@acme".
+	Scopes map[string]string
+
+	// Auth tokens keyed by the registry they are sent to, written as in
+	// .npmrc without the protocol, e.g. "//npm.acme.dev/".
+	AuthTokens map[string]string
+
+	// Whether pnpm refuses to run when its version does not match the
+	// packageManager field, from the `package-manager-strict` setting of
+	// .npmrc. pnpm defaults it to true.
+	PackageManagerStrict bool
+}
+
+// GetRegistryConfig reads the registry configuration of the repository at
+// rootpath from .npmrc and, for berry, .yarnrc.yml, with `${VAR}` environment
+// variable references replaced. Berry ignores .npmrc, so any setting present
+// in both files is taken from .yarnrc.yml. Missing files contribute nothing.
+func GetRegistryConfig(rootpath fs.AbsolutePath) (*RegistryConfig, error) {
+	config := &RegistryConfig{
+		Scopes:               make(map[string]string),
+		AuthTokens:           make(map[string]string),
+		PackageManagerStrict: true,
+	}
+	if err := readNpmrcRegistryConfig(rootpath, config); err != nil {
+		return nil, err
+	}
+	if err := readYarnrcRegistryConfig(rootpath, config); err != nil {
+		return nil, fmt.Errorf(".yarnrc.yml: %w", err)
+	}
+	return config, nil
+}
+
+func readNpmrcRegistryConfig(rootpath fs.AbsolutePath, config *RegistryConfig) error {
+	settings, err := readNpmrc(rootpath)
+	if err != nil {
+		return err
+	}
+	for rawKey, rawValue := range settings {
+		key, err := interpolateEnv(rawKey)
+		if err != nil {
+			return fmt.Errorf(".npmrc: %w", err)
+		}
+		value, err := interpolateEnv(unquoteNpmrcValue(rawValue))
+		if err != nil {
+			return fmt.Errorf(".npmrc: %w", err)
+		}
+
+		switch {
+		case key == "registry":
+			config.Registry = value
+		case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+			config.Scopes[strings.TrimSuffix(key, ":registry")] = value
+		case strings.HasPrefix(key, "//") && strings.HasSuffix(key, ":_authToken"):
+			config.AuthTokens[strings.TrimSuffix(key, ":_authToken")] = value
+		case key == "package-manager-strict":
+			strict, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf(".npmrc: invalid package-manager-strict %q", value)
+			}
+			config.PackageManagerStrict = strict
+		}
+	}
+	return nil
+}
+
+// yarnrcRegistry is the registry configuration of a berry .yarnrc.yml.
+type yarnrcRegistry struct {
+	NpmRegistryServer string `yaml:"npmRegistryServer"`
+	NpmAuthToken      string `yaml:"npmAuthToken"`
+	NpmScopes         map[string]struct {
+		NpmRegistryServer string `yaml:"npmRegistryServer"`
+		NpmAuthToken      string `yaml:"npmAuthToken"`
+	} `yaml:"npmScopes"`
+}
+
+func readYarnrcRegistryConfig(rootpath fs.AbsolutePath, config *RegistryConfig) error {
+	yarnrcPath := rootpath.Join(".yarnrc.yml")
+	if !yarnrcPath.FileExists() {
+		return nil
+	}
+	contents, err := yarnrcPath.ReadFile()
+	if err != nil {
+		return err
+	}
+	var yarnrc yarnrcRegistry
+	if err := yaml.Unmarshal(normalizeLineEndings(contents), &yarnrc); err != nil {
+		return err
+	}
+
+	if yarnrc.NpmRegistryServer != "" {
+		registry, err := interpolateEnv(yarnrc.NpmRegistryServer)
+		if err != nil {
+			return err
+		}
+		config.Registry = registry
+	}
+	if err := addYarnrcAuthToken(config, config.Registry, yarnrc.NpmAuthToken); err != nil {
+		return err
+	}
+	for scope, settings := range yarnrc.NpmScopes {
+		// Berry writes scopes without the leading @.
+		scope = "@" + strings.TrimPrefix(scope, "@")
+		registry := config.Registry
+		if settings.NpmRegistryServer != "" {
+			registry, err = interpolateEnv(settings.NpmRegistryServer)
+			if err != nil {
+				return err
+			}
+			config.Scopes[scope] = registry
+		}
+		if err := addYarnrcAuthToken(config, registry, settings.NpmAuthToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addYarnrcAuthToken records token as the auth token for registry, keyed the
+// way .npmrc keys it.
+func addYarnrcAuthToken(config *RegistryConfig, registry string, token string) error {
+	if token == "" || registry == "" {
+		return nil
+	}
+	token, err := interpolateEnv(token)
+	if err != nil {
+		return err
+	}
+	key := registry
+	if i := strings.Index(key, "//"); i >= 0 {
+		key = key[i:]
+	}
+	if !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	config.AuthTokens[key] = token
+	return nil
+}
+
+// envReference matches `${VAR}`, along with the `${VAR?}` form npm uses for
+// optional variables and the `${VAR:-default}` form berry supports.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(\?|:-[^}]*)?\}`)
+
+// interpolateEnv replaces environment variable references in value. It is an
+// error to reference an unset variable without a default or `?`.
+func interpolateEnv(value string) (string, error) {
+	var missing string
+	interpolated := envReference.ReplaceAllStringFunc(value, func(reference string) string {
+		groups := envReference.FindStringSubmatch(reference)
+		name, modifier := groups[1], groups[2]
+		if env, ok := os.LookupEnv(name); ok && (env != "" || !strings.HasPrefix(modifier, ":-")) {
+			return env
+		}
+		switch {
+		case modifier == "?":
+			return ""
+		case strings.HasPrefix(modifier, ":-"):
+			return strings.TrimPrefix(modifier, ":-")
+		}
+		if missing == "" {
+			missing = name
+		}
+		return reference
+	})
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %v is not set", missing)
+	}
+	return interpolated, nil
+}
+
+// unquoteNpmrcValue removes the quotes npm's ini parser allows around values.
+func unquoteNpmrcValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
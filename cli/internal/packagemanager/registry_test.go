@@ -0,0 +1,79 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetRegistryConfig_Npmrc(t *testing.T) {
+	t.Setenv("ACME_TOKEN", "secret")
+	t.Setenv("ACME_HOST", "npm.acme.dev")
+	rootPath := setupFixture(t, map[string]string{
+		".npmrc": `# Company registries
+registry=https://registry.npmjs.org/
+@acme:registry=https://${ACME_HOST}/
+@tools:registry="https://tools.example.com/npm/"
+//${ACME_HOST}/:_authToken=${ACME_TOKEN}
+//tools.example.com/npm/:_authToken=${TOOLS_TOKEN?}
+package-manager-strict=false
+node-linker=hoisted
+`,
+	})
+
+	got, err := GetRegistryConfig(rootPath)
+	assert.NilError(t, err, "GetRegistryConfig")
+	assert.DeepEqual(t, got, &RegistryConfig{
+		Registry: "https://registry.npmjs.org/",
+		Scopes: map[string]string{
+			"@acme":  "https://npm.acme.dev/",
+			"@tools": "https://tools.example.com/npm/",
+		},
+		AuthTokens: map[string]string{
+			"//npm.acme.dev/":          "secret",
+			"//tools.example.com/npm/": "",
+		},
+		PackageManagerStrict: false,
+	})
+}
+
+func TestGetRegistryConfig_Yarnrc(t *testing.T) {
+	t.Setenv("ACME_TOKEN", "secret")
+	rootPath := setupFixture(t, map[string]string{
+		".npmrc": "registry=https://ignored.example.com/\n@acme:registry=https://old.acme.dev/\n",
+		".yarnrc.yml": `npmRegistryServer: "https://registry.yarnpkg.com"
+npmScopes:
+  acme:
+    npmRegistryServer: "https://npm.acme.dev"
+    npmAuthToken: "${ACME_TOKEN}"
+  internal:
+    npmAuthToken: "${INTERNAL_TOKEN:-none}"
+`,
+	})
+
+	got, err := GetRegistryConfig(rootPath)
+	assert.NilError(t, err, "GetRegistryConfig")
+	assert.DeepEqual(t, got, &RegistryConfig{
+		Registry: "https://registry.yarnpkg.com",
+		Scopes:   map[string]string{"@acme": "https://npm.acme.dev"},
+		AuthTokens: map[string]string{
+			"//npm.acme.dev/":         "secret",
+			"//registry.yarnpkg.com/": "none",
+		},
+		PackageManagerStrict: true,
+	})
+}
+
+func TestGetRegistryConfig_Errors(t *testing.T) {
+	got, err := GetRegistryConfig(setupFixture(t, map[string]string{}))
+	assert.NilError(t, err, "GetRegistryConfig")
+	assert.DeepEqual(t, got, &RegistryConfig{Scopes: map[string]string{}, AuthTokens: map[string]string{}, PackageManagerStrict: true})
+
+	rootPath := setupFixture(t, map[string]string{".npmrc": "//npm.acme.dev/:_authToken=${UNSET_ACME_TOKEN}\n"})
+	_, err = GetRegistryConfig(rootPath)
+	assert.ErrorContains(t, err, ".npmrc: environment variable UNSET_ACME_TOKEN is not set")
+
+	rootPath = setupFixture(t, map[string]string{".npmrc": "package-manager-strict=maybe\n"})
+	_, err = GetRegistryConfig(rootPath)
+	assert.ErrorContains(t, err, `.npmrc: invalid package-manager-strict "maybe"`)
+}
@@ -0,0 +1,85 @@
+package packagemanager
+
+import (
+	"os"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// DetectionReport describes how the package manager for a project was
+// determined, for machine consumption. Field names are stable.
+type DetectionReport struct {
+	// The unique identifier of the package manager, e.g. "pnpm".
+	Manager string `json:"manager"`
+
+	// The descriptive name of the package manager, e.g. "nodejs-pnpm".
+	Name string `json:"name"`
+
+	// The package manager version, if it could be determined.
+	Version string `json:"version,omitempty"`
+
+	// The absolute path to the lockfile, whether or not it exists.
+	Lockfile string `json:"lockfile"`
+
+	// The number of workspaces discovered, or zero if workspaces could not be read.
+	WorkspaceCount int `json:"workspaceCount"`
+
+	// The source which determined the package manager.
+	Reason DetectionReason `json:"reason"`
+}
+
+// GetDetectionReport identifies the package manager in use, as
+// GetPackageManagerWithOpts does, and reports how it was determined.
+func GetDetectionReport(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON, opts Opts) (*DetectionReport, error) {
+	packageManager, reason, err := resolvePackageManager(projectDirectory, pkg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DetectionReport{
+		Manager:  packageManager.Slug,
+		Name:     packageManager.Name,
+		Version:  reportedVersion(projectDirectory, pkg, packageManager, reason, opts.VersionCache),
+		Lockfile: packageManager.LockfilePath(projectDirectory).ToStringDuringMigration(),
+		Reason:   reason,
+	}
+	if !packageManager.SinglePackage {
+		if workspaces, err := packageManager.GetWorkspaces(projectDirectory); err == nil {
+			report.WorkspaceCount = len(workspaces)
+		}
+	}
+	return report, nil
+}
+
+// reportedVersion returns the version pinned by the source which determined the
+// package manager, falling back to asking the package manager itself.
+func reportedVersion(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON, packageManager *PackageManager, reason DetectionReason, cache *VersionCache) string {
+	var pinned string
+	switch reason {
+	case ReasonEnvironment:
+		pinned = os.Getenv(packageManagerEnvVar)
+	case ReasonCache:
+		if cache := readPackageManagerCacheFile(projectDirectory); cache != nil {
+			return cache.Version
+		}
+	case ReasonPackageManagerField:
+		if pkg == nil {
+			// identifyPackageManager read the root manifest itself.
+			if read, err := fs.ReadPackageJSON(projectDirectory.Join("package.json").ToStringDuringMigration()); err == nil {
+				pkg = read
+			}
+		}
+		if version := packageManager.declaredVersion(projectDirectory, pkg); version != "" {
+			return version
+		}
+	}
+	if _, version, err := ParsePackageManagerString(pinned); err == nil {
+		return version
+	}
+
+	version, err := packageManager.GetVersionWithCache(projectDirectory.ToStringDuringMigration(), cache)
+	if err != nil {
+		return ""
+	}
+	return version
+}
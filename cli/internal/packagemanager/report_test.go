@@ -0,0 +1,86 @@
+package packagemanager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetDetectionReport(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root"}`,
+		"pnpm-workspace.yaml":      "packages:\n  - apps/*\n  - packages/*\n",
+		"pnpm-lock.yaml":           "lockfileVersion: 5.4\n",
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	report, err := GetDetectionReport(rootPath, &fs.PackageJSON{Name: "root", PackageManager: "pnpm@7.9.0"}, Opts{})
+	assert.NilError(t, err, "GetDetectionReport")
+	assert.DeepEqual(t, report, &DetectionReport{
+		Manager:        "pnpm",
+		Name:           "nodejs-pnpm",
+		Version:        "7.9.0",
+		Lockfile:       rootPath.Join("pnpm-lock.yaml").ToStringDuringMigration(),
+		WorkspaceCount: 2,
+		Reason:         ReasonPackageManagerField,
+	})
+
+	fakeVersionCommands(t, map[string]string{"pnpm": "7.10.0"})
+	report, err = GetDetectionReport(rootPath, &fs.PackageJSON{Name: "root"}, Opts{})
+	assert.NilError(t, err, "GetDetectionReport")
+	assert.Equal(t, report.Reason, ReasonDetected)
+	assert.Equal(t, report.Version, "7.10.0")
+}
+
+func TestDetectionReport_JSON(t *testing.T) {
+	report := &DetectionReport{
+		Manager:        "yarn",
+		Name:           "nodejs-berry",
+		Version:        "3.2.1",
+		Lockfile:       "/repo/yarn.lock",
+		WorkspaceCount: 3,
+		Reason:         ReasonCache,
+	}
+	rendered, err := json.Marshal(report)
+	assert.NilError(t, err, "Marshal")
+	assert.Equal(t, string(rendered), `{"manager":"yarn","name":"nodejs-berry","version":"3.2.1","lockfile":"/repo/yarn.lock","workspaceCount":3,"reason":"cache"}`)
+}
+
+func TestGetDetectionReport_NilPackageJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name:  "packageManager field",
+			files: map[string]string{"package.json": `{"name": "root", "packageManager": "pnpm@8.6.0"}`},
+		},
+		{
+			name:  "packageManager array",
+			files: map[string]string{"package.json": `{"name": "root", "packageManager": ["yarn@3.2.1", "pnpm@8.6.0"]}`},
+		},
+		{
+			name: ".package-manager.yaml",
+			files: map[string]string{
+				"package.json":          `{"name": "root"}`,
+				".package-manager.yaml": "packageManager: pnpm@8.6.0\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spawns := fakeVersionCommands(t, map[string]string{"pnpm": "7.10.0"})
+			tt.files["pnpm-lock.yaml"] = "lockfileVersion: '6.0'\n"
+			rootPath := setupFixture(t, tt.files)
+
+			report, err := GetDetectionReport(rootPath, nil, Opts{})
+			assert.NilError(t, err, "GetDetectionReport")
+			assert.Equal(t, report.Reason, ReasonPackageManagerField)
+			assert.Equal(t, report.Version, "8.6.0")
+			assert.Equal(t, *spawns, 0)
+		})
+	}
+}
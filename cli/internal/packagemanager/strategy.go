@@ -0,0 +1,158 @@
+package packagemanager
+
+import (
+	"os"
+	"sort"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// Confidence ranks a DetectionStrategy against the others. Strategies with a
+// higher confidence are tried first.
+type Confidence int
+
+const (
+	// ConfidenceCache is the default confidence of the detection cache, which
+	// records an earlier result of the other strategies.
+	ConfidenceCache Confidence = 300
+	// ConfidencePackageManagerField is the default confidence of the root
+	// package.json packageManager field, which is an explicit declaration.
+	ConfidencePackageManagerField Confidence = 200
+	// ConfidenceUserAgent is the default confidence of the npm_config_user_agent
+	// variable, set by the package manager that invoked turbo, for example via
+	// `npm exec turbo`. It is consulted only when the lockfiles are ambiguous.
+	ConfidenceUserAgent Confidence = 150
+	// ConfidenceLockfile is the default confidence of detection from the
+	// lockfile and other files in the project directory.
+	ConfidenceLockfile Confidence = 100
+	// ConfidenceMise is the default confidence of the package manager pinned
+	// in the [tools] table of .mise.toml, which is only a fallback since mise
+	// may pin tools the repository does not install with.
+	ConfidenceMise Confidence = 50
+)
+
+// DetectionInput is what a DetectionStrategy may inspect.
+type DetectionInput struct {
+	// The directory containing the root package.json.
+	ProjectDirectory fs.AbsolutePath
+
+	// The root package.json, or nil if there is none.
+	Manifest *fs.PackageJSON
+
+	// Whether the packageManager field, wherever it is declared, and results
+	// derived from it should be ignored. See Opts.IgnorePackageManagerField.
+	IgnorePackageManagerField bool
+
+	// Logger, if set, receives warnings which do not prevent detection.
+	Logger hclog.Logger
+}
+
+// DetectionStrategy identifies the package manager from a single signal.
+type DetectionStrategy struct {
+	// Reason is reported when this strategy identifies the package manager.
+	Reason DetectionReason
+
+	// Confidence orders this strategy relative to the others. Ties are
+	// broken by the order of the strategy list.
+	Confidence Confidence
+
+	// Detect returns the package manager indicated by the signal, or nil if
+	// the signal is absent. An error does not prevent the remaining
+	// strategies from being tried, and is reported only if none of them
+	// identifies the package manager.
+	Detect func(input DetectionInput) (*PackageManager, error)
+}
+
+// DefaultDetectionStrategies returns the strategies used when
+// Opts.Strategies is unset. The returned slice is a copy which may be
+// reordered, filtered, or have its confidences changed before being passed
+// back in Opts.Strategies.
+func DefaultDetectionStrategies() []DetectionStrategy {
+	return []DetectionStrategy{
+		{
+			Reason:     ReasonCache,
+			Confidence: ConfidenceCache,
+			Detect: func(input DetectionInput) (*PackageManager, error) {
+				if input.IgnorePackageManagerField {
+					return nil, nil
+				}
+				return readPackageManagerCache(input.ProjectDirectory), nil
+			},
+		},
+		{
+			// The packageManager field takes precedence over lockfiles, so that
+			// detection does not depend on whether the lockfile is present yet.
+			Reason:     ReasonPackageManagerField,
+			Confidence: ConfidencePackageManagerField,
+			Detect: func(input DetectionInput) (*PackageManager, error) {
+				if input.IgnorePackageManagerField {
+					return nil, nil
+				}
+				// An invalid field is reported only if nothing else
+				// identifies the package manager.
+				packageManager, _, _ := readDeclaredPackageManager(input.ProjectDirectory, input.Manifest)
+				return packageManager, nil
+			},
+		},
+		{
+			// The invoking package manager is trusted over lockfiles only
+			// when they do not clearly identify one: none was found, or
+			// several were. A single lockfile for a different manager wins,
+			// so that `npx turbo` in a pnpm repository still uses pnpm.
+			Reason:     ReasonUserAgent,
+			Confidence: ConfidenceUserAgent,
+			Detect: func(input DetectionInput) (*PackageManager, error) {
+				manager, version, ok := parseUserAgent(os.Getenv("npm_config_user_agent"))
+				if !ok {
+					return nil, nil
+				}
+				packageManager := findPackageManager(manager, version)
+				if packageManager == nil {
+					return nil, nil
+				}
+				detected, err := DetectAll(input.ProjectDirectory)
+				if err != nil {
+					return nil, err
+				}
+				if len(detected) == 1 && detected[0].Name != packageManager.Name {
+					return nil, nil
+				}
+				return packageManager, nil
+			},
+		},
+		{
+			Reason:     ReasonDetected,
+			Confidence: ConfidenceLockfile,
+			Detect: func(input DetectionInput) (*PackageManager, error) {
+				detected, err := DetectAll(input.ProjectDirectory)
+				if err != nil || len(detected) == 0 {
+					return nil, err
+				}
+				// A damaged lockfile still identifies the package manager,
+				// but the install is likely to fail or rewrite it.
+				if warning := detected[0].CheckLockfile(input.ProjectDirectory); warning != nil && input.Logger != nil {
+					input.Logger.Warn(warning.String())
+				}
+				return detected[0], nil
+			},
+		},
+		{
+			Reason:     ReasonMise,
+			Confidence: ConfidenceMise,
+			Detect: func(input DetectionInput) (*PackageManager, error) {
+				return readMisePackageManager(input.ProjectDirectory)
+			},
+		},
+	}
+}
+
+// byConfidence returns strategies ordered from highest to lowest confidence,
+// preserving the given order among equal confidences.
+func byConfidence(strategies []DetectionStrategy) []DetectionStrategy {
+	sorted := append([]DetectionStrategy{}, strategies...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Confidence > sorted[j].Confidence
+	})
+	return sorted
+}
@@ -0,0 +1,268 @@
+package packagemanager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func TestDetectionStrategies_Precedence(t *testing.T) {
+	files := map[string]string{
+		"package.json":   `{"name": "root", "packageManager": "npm@8.19.2"}`,
+		"pnpm-lock.yaml": "lockfileVersion: 5.4\n",
+	}
+	pkg := &fs.PackageJSON{Name: "root", PackageManager: "npm@8.19.2"}
+
+	withConfidence := func(reason DetectionReason, confidence Confidence) []DetectionStrategy {
+		strategies := DefaultDetectionStrategies()
+		for i := range strategies {
+			if strategies[i].Reason == reason {
+				strategies[i].Confidence = confidence
+			}
+		}
+		return strategies
+	}
+	without := func(reason DetectionReason) []DetectionStrategy {
+		var strategies []DetectionStrategy
+		for _, strategy := range DefaultDetectionStrategies() {
+			if strategy.Reason != reason {
+				strategies = append(strategies, strategy)
+			}
+		}
+		return strategies
+	}
+
+	// The field strategy, lowered to tie with the lockfile strategy.
+	var tiedField, lockfile DetectionStrategy
+	for _, strategy := range DefaultDetectionStrategies() {
+		switch strategy.Reason {
+		case ReasonPackageManagerField:
+			tiedField = strategy
+			tiedField.Confidence = ConfidenceLockfile
+		case ReasonDetected:
+			lockfile = strategy
+		}
+	}
+
+	tests := []struct {
+		name       string
+		strategies []DetectionStrategy
+		cached     *PackageManager
+		want       string
+		wantReason DetectionReason
+	}{
+		{
+			name:       "field wins over lockfile by default",
+			want:       "nodejs-npm",
+			wantReason: ReasonPackageManagerField,
+		},
+		{
+			name:       "cache wins over field by default",
+			cached:     &nodejsYarn,
+			want:       "nodejs-yarn",
+			wantReason: ReasonCache,
+		},
+		{
+			name:       "lockfile wins when more confident than the field",
+			strategies: withConfidence(ReasonDetected, ConfidencePackageManagerField+1),
+			want:       "nodejs-pnpm",
+			wantReason: ReasonDetected,
+		},
+		{
+			name:       "lockfile wins when the field is disabled",
+			strategies: without(ReasonPackageManagerField),
+			want:       "nodejs-pnpm",
+			wantReason: ReasonDetected,
+		},
+		{
+			name:       "list order breaks ties",
+			strategies: []DetectionStrategy{lockfile, tiedField},
+			want:       "nodejs-pnpm",
+			wantReason: ReasonDetected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, files)
+			if tt.cached != nil {
+				assert.NilError(t, WritePackageManagerCache(rootPath, tt.cached, "1.22.19"), "WritePackageManagerCache")
+			}
+
+			got, reason, err := resolvePackageManager(rootPath, pkg, Opts{Strategies: tt.strategies})
+			assert.NilError(t, err, "resolvePackageManager")
+			assert.Equal(t, got.Name, tt.want)
+			assert.Equal(t, reason, tt.wantReason)
+		})
+	}
+}
+
+func TestDetectionStrategies_Errors(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{"package.json": `{"name": "root"}`})
+	pkg := &fs.PackageJSON{Name: "root"}
+	failing := DetectionStrategy{
+		Reason:     "failing",
+		Confidence: ConfidenceCache,
+		Detect: func(DetectionInput) (*PackageManager, error) {
+			return nil, errors.New("strategy failed")
+		},
+	}
+	found := DetectionStrategy{
+		Reason:     "found",
+		Confidence: ConfidenceLockfile,
+		Detect: func(DetectionInput) (*PackageManager, error) {
+			return &nodejsBun, nil
+		},
+	}
+
+	got, reason, err := resolvePackageManager(rootPath, pkg, Opts{Strategies: []DetectionStrategy{failing, found}})
+	assert.NilError(t, err, "a later strategy should recover from an earlier error")
+	assert.Equal(t, got.Name, "nodejs-bun")
+	assert.Equal(t, reason, DetectionReason("found"))
+
+	_, reason, err = resolvePackageManager(rootPath, pkg, Opts{Strategies: []DetectionStrategy{failing}})
+	assert.ErrorContains(t, err, "strategy failed")
+	assert.Equal(t, reason, DetectionReason("failing"))
+
+	_, _, err = resolvePackageManager(rootPath, pkg, Opts{Strategies: []DetectionStrategy{}})
+	assert.ErrorContains(t, err, "We did not detect an in-use package manager")
+}
+
+func TestDetectionStrategies_UserAgent(t *testing.T) {
+	const npmUserAgent = "npm/8.19.2 node/v18.12.0 linux x64 workspaces/false"
+	tests := []struct {
+		name       string
+		files      map[string]string
+		userAgent  string
+		want       string
+		wantReason DetectionReason
+	}{
+		{
+			name: "user agent resolves conflicting lockfiles",
+			files: map[string]string{
+				"package.json":      `{"name": "root"}`,
+				"package-lock.json": "{}\n",
+				"pnpm-lock.yaml":    "lockfileVersion: 5.4\n",
+			},
+			userAgent:  npmUserAgent,
+			want:       "nodejs-npm",
+			wantReason: ReasonUserAgent,
+		},
+		{
+			name: "single lockfile wins over user agent",
+			files: map[string]string{
+				"package.json":   `{"name": "root"}`,
+				"pnpm-lock.yaml": "lockfileVersion: 5.4\n",
+			},
+			userAgent:  npmUserAgent,
+			want:       "nodejs-pnpm",
+			wantReason: ReasonDetected,
+		},
+		{
+			name: "user agent is used without a lockfile",
+			files: map[string]string{
+				"package.json": `{"name": "root"}`,
+			},
+			userAgent:  "pnpm/7.14.0 npm/? node/v18.12.0 linux x64",
+			want:       "nodejs-pnpm",
+			wantReason: ReasonUserAgent,
+		},
+		{
+			name: "field wins over user agent",
+			files: map[string]string{
+				"package.json":      `{"name": "root", "packageManager": "pnpm@7.14.0"}`,
+				"package-lock.json": "{}\n",
+				"pnpm-lock.yaml":    "lockfileVersion: 5.4\n",
+			},
+			userAgent:  npmUserAgent,
+			want:       "nodejs-pnpm",
+			wantReason: ReasonPackageManagerField,
+		},
+		{
+			name: "unparseable user agent is ignored",
+			files: map[string]string{
+				"package.json":   `{"name": "root"}`,
+				"pnpm-lock.yaml": "lockfileVersion: 5.4\n",
+			},
+			userAgent:  "curl",
+			want:       "nodejs-pnpm",
+			wantReason: ReasonDetected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootPath := setupFixture(t, tt.files)
+			t.Setenv("npm_config_user_agent", tt.userAgent)
+
+			got, reason, err := resolvePackageManager(rootPath, nil, Opts{})
+			assert.NilError(t, err, "resolvePackageManager")
+			assert.Equal(t, got.Name, tt.want)
+			assert.Equal(t, reason, tt.wantReason)
+		})
+	}
+}
+
+func TestGetPackageManager_DoesNotRunBinaries(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "packageManager field",
+			files: map[string]string{
+				"package.json": `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+			},
+			want: "nodejs-pnpm",
+		},
+		{
+			name: "npm lockfile",
+			files: map[string]string{
+				"package.json":      `{"name": "root"}`,
+				"package-lock.json": "{\"lockfileVersion\": 3}\n",
+			},
+			want: "nodejs-npm",
+		},
+		{
+			name: "unsigned yarn lockfile",
+			files: map[string]string{
+				"package.json": `{"name": "root"}`,
+				"yarn.lock":    "",
+			},
+			want: "nodejs-yarn",
+		},
+		{
+			name: "unsigned yarn lockfile with yarnrc.yml",
+			files: map[string]string{
+				"package.json": `{"name": "root"}`,
+				"yarn.lock":    "",
+				".yarnrc.yml":  "nodeLinker: node-modules\n",
+			},
+			want: "nodejs-berry",
+		},
+		{
+			name: "conflicting lockfiles",
+			files: map[string]string{
+				"package.json":   `{"name": "root"}`,
+				"yarn.lock":      "# yarn lockfile v1\n",
+				"pnpm-lock.yaml": "lockfileVersion: '6.0'\n",
+			},
+			want: "nodejs-yarn",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every binary would succeed, so that any attempt to run one is counted.
+			spawns := fakeVersionCommands(t, map[string]string{
+				"npm": "9.8.1", "pnpm": "8.6.0", "yarn": "1.22.19", "bun": "1.0.0", "corepack": "0.20.0",
+			})
+			rootPath := setupFixture(t, tt.files)
+
+			got, err := GetPackageManager(rootPath, nil)
+			assert.NilError(t, err, "GetPackageManager")
+			assert.Equal(t, got.Name, tt.want)
+			assert.Equal(t, *spawns, 0)
+		})
+	}
+}
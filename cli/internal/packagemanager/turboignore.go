@@ -0,0 +1,78 @@
+package packagemanager
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// turboIgnoreFile excludes workspaces from discovery using gitignore syntax.
+// It may be placed in any directory of the repository, and its patterns are
+// relative to that directory.
+const turboIgnoreFile = ".turboignore"
+
+// applyTurboIgnores returns manifests without those excluded by a
+// .turboignore file in the repository root or in any directory between it and
+// the workspace. A .turboignore within a workspace applies only to the
+// workspaces nested below it.
+func applyTurboIgnores(rootpath fs.AbsolutePath, manifests []string) ([]string, error) {
+	root := rootpath.ToStringDuringMigration()
+	compiled := make(map[string]*gitignore.GitIgnore)
+	ignoreFor := func(dir string) (*gitignore.GitIgnore, error) {
+		if ignore, ok := compiled[dir]; ok {
+			return ignore, nil
+		}
+		var ignore *gitignore.GitIgnore
+		ignorePath := fs.UnsafeToAbsolutePath(filepath.Join(dir, turboIgnoreFile))
+		if ignorePath.FileExists() {
+			contents, err := ignorePath.ReadFile()
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", ignorePath, err)
+			}
+			ignore = gitignore.CompileIgnoreLines(strings.Split(string(normalizeLineEndings(contents)), "\n")...)
+		}
+		compiled[dir] = ignore
+		return ignore, nil
+	}
+
+	members := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		ignored, err := turboIgnored(root, manifest, ignoreFor)
+		if err != nil {
+			return nil, err
+		}
+		if !ignored {
+			members = append(members, manifest)
+		}
+	}
+	return members, nil
+}
+
+// turboIgnored reports whether manifest is excluded by the .turboignore of
+// any directory from root down to the parent of the manifest's workspace.
+func turboIgnored(root string, manifest string, ignoreFor func(dir string) (*gitignore.GitIgnore, error)) (bool, error) {
+	workspaceDir := filepath.Dir(manifest)
+	if workspaceDir == root {
+		return false, nil
+	}
+	rel, err := filepath.Rel(root, workspaceDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false, err
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	dir := root
+	for i := range segments {
+		ignore, err := ignoreFor(dir)
+		if err != nil {
+			return false, err
+		}
+		if ignore != nil && ignore.MatchesPath(strings.Join(segments[i:], "/")+"/package.json") {
+			return true, nil
+		}
+		dir = filepath.Join(dir, segments[i])
+	}
+	return false, nil
+}
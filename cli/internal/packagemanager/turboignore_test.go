@@ -0,0 +1,36 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_GetWorkspaces_TurboIgnore(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                              `{"name": "root", "workspaces": ["apps/*", "packages/**"]}`,
+		".turboignore":                              "# experiments are never workspaces\napps/sandbox\n",
+		"apps/web/package.json":                     `{"name": "web"}`,
+		"apps/sandbox/package.json":                 `{"name": "sandbox"}`,
+		"packages/ui/package.json":                  `{"name": "ui"}`,
+		"packages/legacy/.turboignore":              "old/\n*-fixture\n!kept-fixture\n",
+		"packages/legacy/package.json":              `{"name": "legacy"}`,
+		"packages/legacy/old/package.json":          `{"name": "old"}`,
+		"packages/legacy/test-fixture/package.json": `{"name": "test-fixture"}`,
+		"packages/legacy/kept-fixture/package.json": `{"name": "kept-fixture"}`,
+	})
+
+	want := []string{
+		"apps/web/package.json",
+		"packages/legacy/kept-fixture/package.json",
+		"packages/legacy/package.json",
+		"packages/ui/package.json",
+	}
+	workspaces, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), want)
+
+	fast, err := nodejsNpm.GetWorkspacesFast(rootPath)
+	assert.NilError(t, err, "GetWorkspacesFast")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, fast), want)
+}
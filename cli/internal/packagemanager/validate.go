@@ -0,0 +1,93 @@
+package packagemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// Warning describes a likely configuration mistake which does not prevent
+// turbo from running.
+type Warning struct {
+	// The configuration value the warning is about, e.g. a workspace glob.
+	Subject string
+
+	// A description of the problem and how to fix it.
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%v: %v", w.Subject, w.Message)
+}
+
+// ValidateWorkspaceGlobs returns a warning for each workspace glob which is
+// unlikely to match the workspaces the user intended.
+func ValidateWorkspaceGlobs(rootpath fs.AbsolutePath, globs []string) []Warning {
+	var warnings []Warning
+	for _, glob := range globs {
+		trimmed := strings.TrimSpace(glob)
+		cleaned := path.Clean(filepath.ToSlash(trimmed))
+
+		switch {
+		case trimmed == "":
+			warnings = append(warnings, Warning{
+				Subject: glob,
+				Message: "empty workspace glob matches nothing",
+			})
+		case path.Base(cleaned) == "package.json":
+			warnings = append(warnings, Warning{
+				Subject: glob,
+				Message: fmt.Sprintf("workspace globs match directories, not manifests. Did you mean %q?", path.Dir(cleaned)),
+			})
+		case !hasGlobMeta(cleaned) && !rootpath.Join(filepath.FromSlash(cleaned), "package.json").FileExists():
+			message := "no package.json found in this directory"
+			if rootpath.Join(filepath.FromSlash(cleaned)).DirExists() {
+				message = fmt.Sprintf("%v. Did you mean %q?", message, cleaned+"/*")
+			}
+			warnings = append(warnings, Warning{
+				Subject: glob,
+				Message: message,
+			})
+		}
+	}
+	return warnings
+}
+
+// validateWorkspacesField returns a warning if the workspaces field of the
+// root package.json is a single glob string rather than an array. The string
+// is honored as the only glob, but other tools reject it. Package Managers
+// which do not read their globs from the field are not warned about it.
+func (pm PackageManager) validateWorkspacesField(rootpath fs.AbsolutePath) *Warning {
+	if !pm.readsWorkspaceField {
+		return nil
+	}
+	field := pm.workspaceField
+	if field == "" {
+		field = "workspaces"
+	}
+	contents, err := rootpath.Join("package.json").ReadFile()
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &fields); err != nil {
+		return nil
+	}
+	var glob string
+	if err := json.Unmarshal(fields[field], &glob); err != nil {
+		return nil
+	}
+	return &Warning{
+		Subject: "package.json " + field,
+		Message: fmt.Sprintf("expected an array of globs, not a string. Write [%q] instead", glob),
+	}
+}
+
+// hasGlobMeta reports whether glob contains any glob syntax.
+func hasGlobMeta(glob string) bool {
+	return strings.ContainsAny(glob, "*?[{")
+}
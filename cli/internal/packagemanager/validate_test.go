@@ -0,0 +1,104 @@
+package packagemanager
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"gotest.tools/v3/assert"
+)
+
+func TestValidateWorkspaceGlobs(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+		"docs/package.json":        `{"name": "docs"}`,
+	})
+
+	tests := []struct {
+		name string
+		glob string
+		want []Warning
+	}{
+		{
+			name: "directory without a wildcard",
+			glob: "packages",
+			want: []Warning{{Subject: "packages", Message: `no package.json found in this directory. Did you mean "packages/*"?`}},
+		},
+		{
+			name: "directory with a trailing slash",
+			glob: "packages/",
+			want: []Warning{{Subject: "packages/", Message: `no package.json found in this directory. Did you mean "packages/*"?`}},
+		},
+		{
+			name: "wildcard",
+			glob: "packages/*",
+		},
+		{
+			name: "single workspace directory",
+			glob: "docs",
+		},
+		{
+			name: "missing directory",
+			glob: "apps",
+			want: []Warning{{Subject: "apps", Message: "no package.json found in this directory"}},
+		},
+		{
+			name: "manifest instead of directory",
+			glob: "packages/*/package.json",
+			want: []Warning{{Subject: "packages/*/package.json", Message: `workspace globs match directories, not manifests. Did you mean "packages/*"?`}},
+		},
+		{
+			name: "empty",
+			glob: "",
+			want: []Warning{{Subject: "", Message: "empty workspace glob matches nothing"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateWorkspaceGlobs(rootPath, []string{tt.glob})
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestGetWorkspacesWithOpts_WorkspacesString(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": "packages/*"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+		"apps/web/package.json":    `{"name": "web"}`,
+	})
+	var output bytes.Buffer
+	logger := hclog.New(&hclog.LoggerOptions{Output: &output})
+
+	for _, pm := range []PackageManager{nodejsNpm, nodejsYarn, nodejsBerry, nodejsBun} {
+		output.Reset()
+		workspaces, err := pm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Logger: logger})
+		assert.NilError(t, err, "GetWorkspacesWithOpts")
+		assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{"packages/ui/package.json"})
+		assert.Assert(t, strings.Contains(output.String(), `package.json workspaces: expected an array of globs, not a string. Write ["packages/*"] instead`), output.String())
+	}
+
+	// pnpm reads its globs from pnpm-workspace.yaml, never the field.
+	assert.NilError(t, rootPath.Join("pnpm-workspace.yaml").WriteFile([]byte("packages:\n  - apps/*\n"), 0644), "WriteFile")
+	output.Reset()
+	workspaces, err := nodejsPnpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Logger: logger})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{"apps/web/package.json"})
+	assert.Assert(t, !strings.Contains(output.String(), "package.json workspaces"), output.String())
+}
+
+func TestGetWorkspacesWithOpts_LogsGlobWarnings(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["packages"]}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+	var output bytes.Buffer
+	logger := hclog.New(&hclog.LoggerOptions{Output: &output})
+
+	workspaces, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Logger: logger})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, len(workspaces), 0)
+	assert.Assert(t, strings.Contains(output.String(), `Did you mean "packages/*"?`), output.String())
+}
@@ -0,0 +1,326 @@
+package packagemanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/semver"
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// runCommand runs cmd and returns its standard output, overridable for tests.
+var runCommand = func(cmd *exec.Cmd) ([]byte, error) {
+	return cmd.Output()
+}
+
+// VersionCache memoizes package manager `--version` output keyed by the
+// resolved path of the binary. A binary's version is assumed to be stable for
+// the lifetime of the cache, so entries are never invalidated; use Reset to
+// start over. Versions reported from outside the project directory are not
+// cached.
+type VersionCache struct {
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+// NewVersionCache returns an empty VersionCache.
+func NewVersionCache() *VersionCache {
+	return &VersionCache{versions: make(map[string]string)}
+}
+
+// Reset discards all cached versions.
+func (c *VersionCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versions = make(map[string]string)
+}
+
+// GetVersion returns the output of `<command> --version`, run in
+// projectDirectory, spawning the command only if the binary it resolves to
+// has not been seen before.
+func (c *VersionCache) GetVersion(command string, projectDirectory string) (string, error) {
+	binary, err := lookPath(command)
+	if err != nil {
+		return "", fmt.Errorf("%v binary not found: %w", command, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version, ok := c.versions[binary]; ok {
+		return version, nil
+	}
+
+	out, err := runVersionCommand(binary, projectDirectory)
+	if isChdirError(err) {
+		// Retry from a directory which is more likely to be usable, e.g. in a
+		// read-only container. A Corepack shim there reports its global default
+		// rather than the version pinned by the project, so the result is
+		// returned but not cached.
+		out, err = runVersionCommand(binary, os.TempDir())
+		if isChdirError(err) {
+			return "", &WorkingDirectoryError{Dir: projectDirectory, Err: err}
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	version := strings.TrimSpace(string(out))
+	c.versions[binary] = version
+	return version, nil
+}
+
+// offlineEnv is added to the environment of commands spawned during
+// detection so that they never go online, e.g. for corepack to download the
+// pinned release or for a package manager to check for updates. In an
+// air-gapped environment a missing release then fails fast instead of
+// hanging on the network.
+var offlineEnv = []string{
+	"COREPACK_ENABLE_DOWNLOAD_PROMPT=0",
+	"COREPACK_ENABLE_NETWORK=0",
+	// Otherwise corepack may add a packageManager field to package.json.
+	"COREPACK_ENABLE_AUTO_PIN=0",
+	"npm_config_offline=true",
+	"npm_config_update_notifier=false",
+	"NO_UPDATE_NOTIFIER=1",
+	"YARN_ENABLE_NETWORK=0",
+	"YARN_ENABLE_TELEMETRY=0",
+}
+
+func runVersionCommand(binary string, dir string) ([]byte, error) {
+	if err := checkWorkingDirectory(dir); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(binary, "--version")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), offlineEnv...)
+	return runCommand(cmd)
+}
+
+// ErrUnsupportedVersion is matched by the error returned from
+// CheckMinimumVersion for a version older than MinSupportedVersion.
+var ErrUnsupportedVersion = errors.New("unsupported package manager version")
+
+// UnsupportedVersionError reports a Package Manager version older than the
+// oldest version turbo supports.
+type UnsupportedVersionError struct {
+	// The command of the Package Manager.
+	Command string
+
+	// The version in use.
+	Version string
+
+	// The Package Manager's MinSupportedVersion.
+	Minimum string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("%v %v is not supported. Upgrade to %v %v or newer", e.Command, e.Version, e.Command, e.Minimum)
+}
+
+// Is reports whether target is ErrUnsupportedVersion.
+func (e *UnsupportedVersionError) Is(target error) bool {
+	return target == ErrUnsupportedVersion
+}
+
+// CheckMinimumVersion returns an UnsupportedVersionError if version is older
+// than the Package Manager's MinSupportedVersion. Prereleases of the minimum
+// version are accepted.
+func (pm PackageManager) CheckMinimumVersion(version string) error {
+	if pm.MinSupportedVersion == "" {
+		return nil
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("could not parse %v version %q: %w", pm.Command, version, err)
+	}
+	constraint, err := semver.NewConstraint(">=" + pm.MinSupportedVersion + "-0")
+	if err != nil {
+		return fmt.Errorf("could not create constraint: %w", err)
+	}
+	if !constraint.Check(v) {
+		return &UnsupportedVersionError{Command: pm.Command, Version: version, Minimum: pm.MinSupportedVersion}
+	}
+	return nil
+}
+
+// WorkingDirectoryError reports that a package manager command could not be
+// started because its working directory could not be entered.
+type WorkingDirectoryError struct {
+	// The working directory the command was to run in.
+	Dir string
+
+	// The underlying failure.
+	Err error
+}
+
+func (e *WorkingDirectoryError) Error() string {
+	return fmt.Sprintf("could not set working directory %v: %v", e.Dir, e.Err)
+}
+
+func (e *WorkingDirectoryError) Unwrap() error {
+	return e.Err
+}
+
+// checkWorkingDirectory returns a chdir *os.PathError if dir cannot be entered
+// as a command's working directory. os/exec only checks that the directory
+// exists, so an existing directory without search permission would otherwise
+// fail as an indistinct fork/exec error.
+func checkWorkingDirectory(dir string) error {
+	// Looking up "." within dir requires search permission on dir.
+	if _, err := os.Stat(dir + string(filepath.Separator) + "."); err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return &os.PathError{Op: "chdir", Path: dir, Err: err}
+	}
+	return nil
+}
+
+// isChdirError reports whether err is a failure to enter a command's working directory.
+func isChdirError(err error) bool {
+	var pathErr *os.PathError
+	return errors.As(err, &pathErr) && pathErr.Op == "chdir"
+}
+
+// versionCache is shared by all version lookups within a run.
+var versionCache = NewVersionCache()
+
+// GetPackageManagerVersionFromCmd returns the output of `<command> --version`,
+// run in projectDirectory. Results are cached for the lifetime of the process.
+func GetPackageManagerVersionFromCmd(command string, projectDirectory string) (string, error) {
+	return versionCache.GetVersion(command, projectDirectory)
+}
+
+// VersionSource identifies where ResolveVersion found a version.
+type VersionSource string
+
+const (
+	// VersionSourcePackageManagerField means the version was pinned by the
+	// packageManager field of the project's package.json.
+	VersionSourcePackageManagerField VersionSource = "packageManager-field"
+	// VersionSourceUserAgent means the version was read from the
+	// npm_config_user_agent environment variable set by the package manager
+	// running turbo.
+	VersionSourceUserAgent VersionSource = "user-agent"
+	// VersionSourceCommand means the version was the output of `<command> --version`.
+	VersionSourceCommand VersionSource = "command"
+)
+
+// GetVersion returns the version of the Package Manager's command, as
+// resolved by ResolveVersion.
+func (pm PackageManager) GetVersion(projectDirectory string) (string, error) {
+	return pm.GetVersionWithCache(projectDirectory, nil)
+}
+
+// GetVersionWithCache returns the version of the Package Manager's command, as
+// resolved by ResolveVersionWithCache.
+func (pm PackageManager) GetVersionWithCache(projectDirectory string, cache *VersionCache) (string, error) {
+	version, _, err := pm.ResolveVersionWithCache(projectDirectory, cache)
+	return version, err
+}
+
+// ResolveVersion returns the version of the Package Manager's command and
+// where it was found. Sources are tried in order, and the first to yield a
+// version of this Package Manager wins:
+//
+//  1. The packageManager field declared in projectDirectory, usually in package.json.
+//     Corepack shims honor the pin, and running the shim may trigger a download.
+//  2. npm_config_user_agent, set when turbo is run by the package manager.
+//  3. The output of `<command> --version`, run in projectDirectory.
+//
+// Only the last of these spawns a process.
+func (pm PackageManager) ResolveVersion(projectDirectory string) (string, VersionSource, error) {
+	return pm.ResolveVersionWithCache(projectDirectory, nil)
+}
+
+// ResolveVersionWithCache resolves the version as ResolveVersion does, but
+// memoizes `<command> --version` in cache rather than in the cache shared by
+// the process, unless cache is nil.
+func (pm PackageManager) ResolveVersionWithCache(projectDirectory string, cache *VersionCache) (string, VersionSource, error) {
+	if cache == nil {
+		cache = versionCache
+	}
+	if version := pm.pinnedVersion(projectDirectory); version != "" {
+		return version, VersionSourcePackageManagerField, nil
+	}
+	if version := pm.userAgentVersion(os.Getenv("npm_config_user_agent")); version != "" {
+		return version, VersionSourceUserAgent, nil
+	}
+	version, err := cache.GetVersion(pm.Command, projectDirectory)
+	if err != nil {
+		return "", "", err
+	}
+	return version, VersionSourceCommand, nil
+}
+
+// userAgentVersion returns the version of this Package Manager named by a
+// user agent such as `pnpm/8.6.0 npm/? node/v18.16.0 darwin arm64`, or "".
+func (pm PackageManager) userAgentVersion(userAgent string) string {
+	manager, version, ok := parseUserAgent(userAgent)
+	if !ok {
+		return ""
+	}
+	if matches, err := pm.Matches(manager, version); err != nil || !matches {
+		return ""
+	}
+	return version
+}
+
+// parseUserAgent returns the name and version of the package manager which
+// produced a user agent, taken from its leading `name/version` token.
+func parseUserAgent(userAgent string) (manager string, version string, ok bool) {
+	fields := strings.Fields(userAgent)
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	manager, version, ok = strings.Cut(fields[0], "/")
+	if !ok || version == "" {
+		return "", "", false
+	}
+	return manager, version, true
+}
+
+// pinnedVersion returns the version of this Package Manager pinned by the
+// packageManager field declared in projectDirectory, or "". See
+// packageManagerSources for where the field may be declared.
+func (pm PackageManager) pinnedVersion(projectDirectory string) string {
+	pkg, err := fs.ReadPackageJSON(filepath.Join(projectDirectory, "package.json"))
+	if err != nil {
+		pkg = nil
+	}
+	return pm.declaredVersion(fs.UnsafeToAbsolutePath(projectDirectory), pkg)
+}
+
+// declaredVersion returns the version of this Package Manager pinned by the
+// packageManager field declared in projectDirectory, with pkg as its root
+// package.json, or "".
+func (pm PackageManager) declaredVersion(projectDirectory fs.AbsolutePath, pkg *fs.PackageJSON) string {
+	declaration, _, err := readPackageManagerDeclaration(projectDirectory, pkg)
+	if err != nil || declaration == nil {
+		return ""
+	}
+	entries := declaration.PackageManagers
+	if declaration.PackageManager != "" {
+		entries = []string{declaration.PackageManager}
+	}
+	for _, entry := range entries {
+		manager, version, err := ParsePackageManagerString(entry)
+		if err != nil {
+			continue
+		}
+		if matches, err := pm.Matches(manager, version); err == nil && matches {
+			return version
+		}
+	}
+	return ""
+}
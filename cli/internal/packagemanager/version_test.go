@@ -0,0 +1,298 @@
+package packagemanager
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// fakeVersionCommands replaces binary lookup, command execution, and the
+// shared version cache for the duration of a test, returning a pointer to the
+// number of spawned commands.
+func fakeVersionCommands(tb testing.TB, versions map[string]string) *int {
+	originalLookPath, originalRunCommand, originalVersionCache := lookPath, runCommand, versionCache
+	tb.Cleanup(func() {
+		lookPath, runCommand, versionCache = originalLookPath, originalRunCommand, originalVersionCache
+	})
+	versionCache = NewVersionCache()
+	tb.Setenv("npm_config_user_agent", "")
+
+	spawns := 0
+	lookPath = func(file string) (string, error) {
+		if _, ok := versions[file]; !ok {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/local/bin/" + file, nil
+	}
+	runCommand = func(cmd *exec.Cmd) ([]byte, error) {
+		spawns++
+		for command, version := range versions {
+			if cmd.Path == "/usr/local/bin/"+command {
+				return []byte(version + "\n"), nil
+			}
+		}
+		return nil, exec.ErrNotFound
+	}
+	return &spawns
+}
+
+func TestGetVersion(t *testing.T) {
+	spawns := fakeVersionCommands(t, map[string]string{"pnpm": "7.9.0", "npm": "8.19.1"})
+	cache := NewVersionCache()
+
+	for i := 0; i < 3; i++ {
+		version, err := nodejsPnpm.GetVersionWithCache(t.TempDir(), cache)
+		assert.NilError(t, err, "GetVersionWithCache")
+		assert.Equal(t, version, "7.9.0")
+	}
+	assert.Equal(t, *spawns, 1)
+
+	version, err := nodejsNpm.GetVersionWithCache(t.TempDir(), cache)
+	assert.NilError(t, err, "GetVersionWithCache")
+	assert.Equal(t, version, "8.19.1")
+	assert.Equal(t, *spawns, 2)
+
+	// The shared cache is separate from the injected one.
+	_, err = nodejsPnpm.GetVersion(t.TempDir())
+	assert.NilError(t, err, "GetVersion")
+	assert.Equal(t, *spawns, 3)
+
+	cache.Reset()
+	_, err = nodejsPnpm.GetVersionWithCache(t.TempDir(), cache)
+	assert.NilError(t, err, "GetVersionWithCache")
+	assert.Equal(t, *spawns, 4)
+}
+
+func TestGetVersion_NotFound(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{})
+
+	_, err := nodejsPnpm.GetVersion(t.TempDir())
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+}
+
+func BenchmarkGetVersion(b *testing.B) {
+	spawns := fakeVersionCommands(b, map[string]string{"pnpm": "7.9.0"})
+	projectDirectory := b.TempDir()
+
+	for i := 0; i < b.N; i++ {
+		// Simulate looking up the version once per workspace.
+		for workspace := 0; workspace < 100; workspace++ {
+			if _, err := nodejsPnpm.GetVersion(projectDirectory); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(*spawns)/float64(b.N), "spawns/op")
+}
+
+// lockedDir returns a new directory which cannot be entered.
+func lockedDir(t *testing.T) string {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	dir := t.TempDir()
+	assert.NilError(t, os.Chmod(dir, 0), "Chmod")
+	t.Cleanup(func() { _ = os.Chmod(dir, 0755) })
+	return dir
+}
+
+func TestGetVersion_WorkingDirectory(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"pnpm": "7.9.0"})
+	projectDirectory := lockedDir(t)
+	fakeRunCommand := runCommand
+	var dirs []string
+	runCommand = func(cmd *exec.Cmd) ([]byte, error) {
+		dirs = append(dirs, cmd.Dir)
+		return fakeRunCommand(cmd)
+	}
+
+	version, err := nodejsPnpm.GetVersion(projectDirectory)
+	assert.NilError(t, err, "GetVersion")
+	assert.Equal(t, version, "7.9.0")
+	assert.DeepEqual(t, dirs, []string{os.TempDir()})
+
+	// The fallback result is not cached, so an enterable project directory
+	// still gets its own lookup.
+	otherDirectory := t.TempDir()
+	_, err = nodejsPnpm.GetVersion(otherDirectory)
+	assert.NilError(t, err, "GetVersion")
+	_, err = nodejsPnpm.GetVersion(otherDirectory)
+	assert.NilError(t, err, "GetVersion")
+	assert.DeepEqual(t, dirs, []string{os.TempDir(), otherDirectory})
+}
+
+func TestGetVersion_OfflineEnv(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{"pnpm": "7.9.0"})
+	t.Setenv("TURBO_TEST_INHERITED", "1")
+	fakeRunCommand := runCommand
+	var env []string
+	runCommand = func(cmd *exec.Cmd) ([]byte, error) {
+		env = cmd.Env
+		return fakeRunCommand(cmd)
+	}
+
+	_, err := nodejsPnpm.GetVersion(t.TempDir())
+	assert.NilError(t, err, "GetVersion")
+	set := make(map[string]bool, len(env))
+	for _, variable := range env {
+		set[variable] = true
+	}
+	for _, want := range []string{"COREPACK_ENABLE_DOWNLOAD_PROMPT=0", "COREPACK_ENABLE_NETWORK=0", "npm_config_offline=true", "YARN_ENABLE_NETWORK=0", "TURBO_TEST_INHERITED=1"} {
+		assert.Assert(t, set[want], "missing %v in %v", want, env)
+	}
+}
+
+func TestGetVersion_WorkingDirectoryError(t *testing.T) {
+	spawns := fakeVersionCommands(t, map[string]string{"pnpm": "7.9.0"})
+	projectDirectory := lockedDir(t)
+	t.Setenv("TMPDIR", lockedDir(t))
+
+	_, err := nodejsPnpm.GetVersion(projectDirectory)
+	var dirErr *WorkingDirectoryError
+	assert.Assert(t, errors.As(err, &dirErr), "expected a WorkingDirectoryError, got %v", err)
+	assert.Equal(t, dirErr.Dir, projectDirectory)
+	assert.Assert(t, errors.Is(err, os.ErrPermission))
+	assert.Equal(t, *spawns, 0)
+}
+
+func TestGetVersion_PackageManagerField(t *testing.T) {
+	spawns := fakeVersionCommands(t, map[string]string{"pnpm": "7.9.0", "yarn": "1.22.19"})
+	rootPath := setupFixture(t, map[string]string{
+		"package.json": `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+	})
+
+	version, err := nodejsPnpm.GetVersion(rootPath.ToStringDuringMigration())
+	assert.NilError(t, err, "GetVersion")
+	assert.Equal(t, version, "8.6.0")
+	assert.Equal(t, *spawns, 0)
+
+	// A pin for a different package manager is not trusted.
+	version, err = nodejsYarn.GetVersion(rootPath.ToStringDuringMigration())
+	assert.NilError(t, err, "GetVersion")
+	assert.Equal(t, version, "1.22.19")
+	assert.Equal(t, *spawns, 1)
+}
+
+func TestResolveVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageJSON string
+		userAgent   string
+		wantVersion string
+		wantSource  VersionSource
+		wantSpawns  int
+	}{
+		{
+			name:        "packageManager field",
+			packageJSON: `{"name": "root", "packageManager": "pnpm@8.6.0"}`,
+			userAgent:   "pnpm/8.5.1 npm/? node/v18.16.0 darwin arm64",
+			wantVersion: "8.6.0",
+			wantSource:  VersionSourcePackageManagerField,
+		},
+		{
+			name:        "user agent",
+			packageJSON: `{"name": "root"}`,
+			userAgent:   "pnpm/8.5.1 npm/? node/v18.16.0 darwin arm64",
+			wantVersion: "8.5.1",
+			wantSource:  VersionSourceUserAgent,
+		},
+		{
+			name:        "user agent of another package manager",
+			packageJSON: `{"name": "root"}`,
+			userAgent:   "yarn/1.22.19 npm/? node/v18.16.0 linux x64",
+			wantVersion: "7.9.0",
+			wantSource:  VersionSourceCommand,
+			wantSpawns:  1,
+		},
+		{
+			name:        "command",
+			packageJSON: `{"name": "root"}`,
+			wantVersion: "7.9.0",
+			wantSource:  VersionSourceCommand,
+			wantSpawns:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spawns := fakeVersionCommands(t, map[string]string{"pnpm": "7.9.0"})
+			t.Setenv("npm_config_user_agent", tt.userAgent)
+			rootPath := setupFixture(t, map[string]string{"package.json": tt.packageJSON})
+
+			version, source, err := nodejsPnpm.ResolveVersion(rootPath.ToStringDuringMigration())
+			assert.NilError(t, err, "ResolveVersion")
+			assert.Equal(t, version, tt.wantVersion)
+			assert.Equal(t, source, tt.wantSource)
+			assert.Equal(t, *spawns, tt.wantSpawns)
+		})
+	}
+}
+
+func TestResolveVersion_UserAgentYarnVariant(t *testing.T) {
+	fakeVersionCommands(t, map[string]string{})
+	t.Setenv("npm_config_user_agent", "yarn/3.2.1 npm/? node/v18.16.0 linux x64")
+
+	version, source, err := nodejsBerry.ResolveVersion(t.TempDir())
+	assert.NilError(t, err, "ResolveVersion")
+	assert.Equal(t, version, "3.2.1")
+	assert.Equal(t, source, VersionSourceUserAgent)
+
+	_, _, err = nodejsYarn.ResolveVersion(t.TempDir())
+	assert.ErrorContains(t, err, "yarn binary not found")
+}
+
+func TestCheckMinimumVersion(t *testing.T) {
+	tests := []struct {
+		pm      PackageManager
+		version string
+		wantErr bool
+	}{
+		{pm: nodejsNpm, version: "6.14.17", wantErr: true},
+		{pm: nodejsNpm, version: "7.0.0-beta.1"},
+		{pm: nodejsNpm, version: "8.19.2"},
+		{pm: nodejsYarn, version: "0.27.5", wantErr: true},
+		{pm: nodejsYarn, version: "1.22.19"},
+		{pm: nodejsBerry, version: "3.2.3"},
+		{pm: nodejsPnpm, version: "5.18.10", wantErr: true},
+		{pm: nodejsPnpm, version: "7.14.0"},
+		{pm: nodejsBun, version: "0.8.1", wantErr: true},
+		{pm: nodejsBun, version: "1.0.25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pm.Name+"@"+tt.version, func(t *testing.T) {
+			err := tt.pm.CheckMinimumVersion(tt.version)
+			if !tt.wantErr {
+				assert.NilError(t, err, "CheckMinimumVersion")
+				return
+			}
+			assert.Assert(t, errors.Is(err, ErrUnsupportedVersion), "expected ErrUnsupportedVersion, got %v", err)
+			assert.ErrorContains(t, err, "or newer")
+		})
+	}
+
+	assert.ErrorContains(t, nodejsNpm.CheckMinimumVersion("latest"), "could not parse npm version")
+}
+
+func TestGetPackageManager_EnforceMinimumVersion(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":      `{"name": "root", "workspaces": ["packages/*"]}`,
+		"package-lock.json": "{}\n",
+	})
+
+	fakeVersionCommands(t, map[string]string{"npm": "6.14.17"})
+	_, err := GetPackageManagerWithOpts(rootPath, nil, Opts{EnforceMinimumVersion: true, VersionCache: NewVersionCache()})
+	assert.Assert(t, errors.Is(err, ErrUnsupportedVersion), "expected ErrUnsupportedVersion, got %v", err)
+
+	packageManager, err := GetPackageManagerWithOpts(rootPath, nil, Opts{})
+	assert.NilError(t, err, "GetPackageManagerWithOpts without enforcement")
+	assert.Equal(t, packageManager.Name, "nodejs-npm")
+
+	// A fresh cache does not remember the old version.
+	fakeVersionCommands(t, map[string]string{"npm": "8.19.2"})
+	packageManager, err = GetPackageManagerWithOpts(rootPath, nil, Opts{EnforceMinimumVersion: true, VersionCache: NewVersionCache()})
+	assert.NilError(t, err, "GetPackageManagerWithOpts")
+	assert.Equal(t, packageManager.Name, "nodejs-npm")
+}
@@ -0,0 +1,158 @@
+package packagemanager
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// workspaceConfigFiles are the files, relative to the repository root, whose
+// contents can change which workspaces are discovered.
+var workspaceConfigFiles = []string{"package.json", "pnpm-workspace.yaml", "turbo.json", workspaceIncludeFile}
+
+// WorkspaceCache memoizes workspace discovery for long-running processes,
+// such as watch mode, which discover the workspaces of the same repository
+// repeatedly. An entry is reused for as long as the modification times it
+// observed are unchanged: those of the workspace configuration files, of the
+// directories the workspace globs are rooted in, and of every directory
+// between the repository root and a discovered manifest, plus, for a
+// Recursive discovery, those of the manifests and their configuration files
+// and glob directories. Adding or removing
+// a workspace changes at least one of these, except for a workspace created
+// deep inside a new directory under a `**` glob, so the cache is a
+// heuristic; use Reset to force rediscovery.
+type WorkspaceCache struct {
+	mu      sync.Mutex
+	entries map[string]workspaceCacheEntry
+}
+
+type workspaceCacheEntry struct {
+	manifests []string
+
+	// Modification times keyed by absolute path. A path which did not exist
+	// is recorded with the zero time.
+	mtimes map[string]time.Time
+}
+
+// NewWorkspaceCache returns an empty WorkspaceCache.
+func NewWorkspaceCache() *WorkspaceCache {
+	return &WorkspaceCache{entries: make(map[string]workspaceCacheEntry)}
+}
+
+// Reset discards all cached discoveries.
+func (c *WorkspaceCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]workspaceCacheEntry)
+}
+
+// get returns the cached manifests for key, if its observed paths are unchanged.
+func (c *WorkspaceCache) get(key string) ([]string, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	for observed, mtime := range entry.mtimes {
+		if !modTime(observed).Equal(mtime) {
+			return nil, false
+		}
+	}
+	return append([]string{}, entry.manifests...), true
+}
+
+// put caches manifests under key along with the modification times of observed.
+func (c *WorkspaceCache) put(key string, manifests []string, observed []string) {
+	mtimes := make(map[string]time.Time, len(observed))
+	for _, p := range observed {
+		mtimes[p] = modTime(p)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = workspaceCacheEntry{manifests: append([]string{}, manifests...), mtimes: mtimes}
+}
+
+// workspaceCacheKey identifies a discovery by everything other than the
+// filesystem which affects its result. Matchers cannot be compared, so a
+// WorkspaceCache should only be shared by callers using the same Matcher.
+func (pm PackageManager) workspaceCacheKey(rootpath fs.AbsolutePath, opts WorkspaceOpts) string {
+	return fmt.Sprintf("%v\x00%v\x00%v\x00%v\x00%v\x00%v\x00%v", rootpath, pm.Name, pm.workspaceField, opts.Recursive, opts.IncludeRoot, opts.MaxDepth, opts.FollowSymlinks)
+}
+
+// workspaceCachePaths returns the paths whose modification times a cached
+// discovery of manifests from globs depends on. A recursive discovery also
+// depends on every manifest, any of which may become a nested root, and on
+// the configuration files and glob directories of each nested root.
+func (pm PackageManager) workspaceCachePaths(rootpath fs.AbsolutePath, globs []string, manifests []string, recursive bool) []string {
+	root := rootpath.ToStringDuringMigration()
+	observed := map[string]bool{}
+	// .turboignore files are edited in place without touching their
+	// directory, so each is observed alongside it.
+	observeDir := func(dir string) {
+		observed[dir] = true
+		observed[filepath.Join(dir, turboIgnoreFile)] = true
+	}
+	observeDir(root)
+	for _, file := range workspaceConfigFiles {
+		observed[rootpath.Join(filepath.FromSlash(file)).ToStringDuringMigration()] = true
+	}
+	observeGlobs := func(base fs.AbsolutePath, globs []string) {
+		for _, glob := range globs {
+			observeDir(base.Join(filepath.FromSlash(globBase(glob))).ToStringDuringMigration())
+		}
+	}
+	observeGlobs(rootpath, globs)
+	for _, manifest := range manifests {
+		for dir := filepath.Dir(manifest); strings.HasPrefix(dir, root) && dir != root; dir = filepath.Dir(dir) {
+			observeDir(dir)
+		}
+		if !recursive {
+			continue
+		}
+		nestedRoot := fs.AbsolutePathFromUpstream(filepath.Dir(manifest))
+		for _, file := range workspaceConfigFiles {
+			observed[nestedRoot.Join(filepath.FromSlash(file)).ToStringDuringMigration()] = true
+		}
+		if nestedGlobs, err := pm.workspaceGlobs(nestedRoot); err == nil {
+			observeGlobs(nestedRoot, nestedGlobs)
+		}
+	}
+
+	paths := make([]string, 0, len(observed))
+	for p := range observed {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// globBase returns the leading segments of a slash-separated glob which
+// contain no glob syntax, or "." if the first segment does.
+func globBase(glob string) string {
+	var base []string
+	for _, segment := range strings.Split(path.Clean(filepath.ToSlash(glob)), "/") {
+		if hasGlobMeta(segment) {
+			break
+		}
+		base = append(base, segment)
+	}
+	if len(base) == 0 {
+		return "."
+	}
+	return path.Join(base...)
+}
+
+// modTime returns the modification time of p, or the zero time if it cannot
+// be read.
+func modTime(p string) time.Time {
+	info, err := fs.UnsafeToAbsolutePath(p).Lstat()
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
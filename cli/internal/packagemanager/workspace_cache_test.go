@@ -0,0 +1,106 @@
+package packagemanager
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"gotest.tools/v3/assert"
+)
+
+func TestWorkspaceCache(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":    `{"name": "web"}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+	scans := 0
+	opts := WorkspaceOpts{
+		Cache: NewWorkspaceCache(),
+		Matcher: func(basePath string, includePatterns []string, excludePatterns []string) ([]string, error) {
+			scans++
+			return GlobbyMatcher(basePath, includePatterns, excludePatterns)
+		},
+	}
+	touch := func(path fs.AbsolutePath) {
+		t.Helper()
+		later := time.Now().Add(time.Hour)
+		assert.NilError(t, os.Chtimes(path.ToStringDuringMigration(), later, later), "Chtimes")
+	}
+
+	first, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, opts)
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, scans, 1)
+
+	second, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, opts)
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, scans, 1)
+	assert.DeepEqual(t, second, first)
+
+	// Other package managers and options are cached separately.
+	_, err = nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{Cache: opts.Cache, Matcher: opts.Matcher, IncludeRoot: true})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, scans, 2)
+
+	// A workspace added to a glob's directory changes that directory.
+	assert.NilError(t, rootPath.Join("packages", "config").MkdirAll(), "MkdirAll")
+	assert.NilError(t, rootPath.Join("packages", "config", "package.json").WriteFile([]byte(`{"name": "config"}`), 0644), "WriteFile")
+	touch(rootPath.Join("packages"))
+
+	third, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, opts)
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, scans, 3)
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, third), []string{"apps/web/package.json", "packages/config/package.json", "packages/ui/package.json"})
+
+	// A manifest removed from a workspace directory changes that directory.
+	assert.NilError(t, os.Remove(rootPath.Join("apps", "web", "package.json").ToStringDuringMigration()), "Remove")
+	touch(rootPath.Join("apps", "web"))
+
+	fourth, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, opts)
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, scans, 4)
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, fourth), []string{"packages/config/package.json", "packages/ui/package.json"})
+
+	opts.Cache.Reset()
+	_, err = nodejsNpm.GetWorkspacesWithOpts(rootPath, opts)
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.Equal(t, scans, 5)
+}
+
+func Test_globBase(t *testing.T) {
+	tests := map[string]string{
+		"packages/*":       "packages",
+		"./apps/web":       "apps/web",
+		"**":               ".",
+		"libs/{a,b}/*":     "libs",
+		"tools/*/packages": "tools",
+	}
+	for glob, want := range tests {
+		assert.Equal(t, globBase(glob), want, glob)
+	}
+}
+
+func TestWorkspaceCache_Recursive(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                            `{"name": "root", "workspaces": ["apps/*"]}`,
+		"apps/platform/package.json":              `{"name": "platform", "workspaces": ["packages/*"]}`,
+		"apps/platform/packages/api/package.json": `{"name": "api"}`,
+		"apps/platform/libs/db/package.json":      `{"name": "db"}`,
+	})
+	opts := WorkspaceOpts{Cache: NewWorkspaceCache(), Recursive: true}
+
+	first, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, opts)
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, first), []string{"apps/platform/package.json", "apps/platform/packages/api/package.json"})
+
+	// Editing a nested root's workspaces field touches no directory.
+	manifest := rootPath.Join("apps", "platform", "package.json")
+	assert.NilError(t, manifest.WriteFile([]byte(`{"name": "platform", "workspaces": ["packages/*", "libs/*"]}`), 0644), "WriteFile")
+	later := time.Now().Add(time.Hour)
+	assert.NilError(t, os.Chtimes(manifest.ToStringDuringMigration(), later, later), "Chtimes")
+
+	second, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, opts)
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, second), []string{"apps/platform/libs/db/package.json", "apps/platform/package.json", "apps/platform/packages/api/package.json"})
+}
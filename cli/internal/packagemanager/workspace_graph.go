@@ -0,0 +1,314 @@
+package packagemanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+)
+
+// WorkspaceGraph is the workspaces of a repository and the internal
+// dependencies between them. Each edge runs from a workspace to a workspace
+// it depends on.
+type WorkspaceGraph struct {
+	// Workspaces keyed by name.
+	Workspaces map[string]WorkspacePackage
+
+	// Sorted direct dependency names keyed by workspace name.
+	dependencies map[string][]string
+
+	// Sorted direct dependent names keyed by workspace name.
+	dependents map[string][]string
+}
+
+// BuildWorkspaceGraph returns the graph of internal dependencies between the
+// workspaces at rootpath. A workspace depends on another if it declares it in
+// its dependencies, devDependencies, or optionalDependencies, or if the
+// lockfile links it to the other workspace. A missing or unreadable-format
+// lockfile leaves only the edges declared by manifests. Self-dependencies are
+// ignored. AffectedWorkspaces, InternalDependencyClosure, and TopologicalOrder
+// all walk this graph, so they agree on which workspaces depend on which.
+func (pm PackageManager) BuildWorkspaceGraph(rootpath fs.AbsolutePath) (*WorkspaceGraph, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	return pm.buildWorkspaceGraph(rootpath, workspaces)
+}
+
+// buildWorkspaceGraph returns the graph of internal dependencies between
+// workspaces, as described by BuildWorkspaceGraph.
+func (pm PackageManager) buildWorkspaceGraph(rootpath fs.AbsolutePath, workspaces []WorkspacePackage) (*WorkspaceGraph, error) {
+	graph := &WorkspaceGraph{
+		Workspaces:   make(map[string]WorkspacePackage, len(workspaces)),
+		dependencies: make(map[string][]string, len(workspaces)),
+		dependents:   make(map[string][]string, len(workspaces)),
+	}
+	for _, workspace := range workspaces {
+		graph.Workspaces[workspace.Name] = workspace
+	}
+
+	var lockfileDependencies map[string][]string
+	lockfile, err := pm.ReadLockfile(rootpath)
+	switch {
+	case err == nil:
+		lockfileDependencies = lockfile.WorkspaceDependencies()
+	case pm.readLockfile == nil && pm.parseLockfile == nil, errors.Is(err, os.ErrNotExist), errors.Is(err, ErrBinaryBunLockfile):
+	default:
+		return nil, err
+	}
+
+	for _, workspace := range workspaces {
+		edges := make(map[string]bool)
+		for _, section := range []map[string]string{
+			workspace.Manifest.Dependencies,
+			workspace.Manifest.DevDependencies,
+			workspace.Manifest.OptionalDependencies,
+		} {
+			for name := range section {
+				edges[name] = true
+			}
+		}
+		for _, name := range lockfileDependencies[workspace.Dir] {
+			edges[name] = true
+		}
+		for name := range edges {
+			// Links to packages outside the workspaces are not internal dependencies.
+			if _, ok := graph.Workspaces[name]; !ok || name == workspace.Name {
+				continue
+			}
+			graph.dependencies[workspace.Name] = append(graph.dependencies[workspace.Name], name)
+			graph.dependents[name] = append(graph.dependents[name], workspace.Name)
+		}
+	}
+	for _, edges := range graph.dependencies {
+		sort.Strings(edges)
+	}
+	for _, edges := range graph.dependents {
+		sort.Strings(edges)
+	}
+	return graph, nil
+}
+
+// Names returns the names of every workspace in the graph, sorted.
+func (g *WorkspaceGraph) Names() []string {
+	names := make([]string, 0, len(g.Workspaces))
+	for name := range g.Workspaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dependencies returns the names of the workspaces name directly depends on,
+// sorted.
+func (g *WorkspaceGraph) Dependencies(name string) []string {
+	return append([]string{}, g.dependencies[name]...)
+}
+
+// Dependents returns the names of the workspaces which directly depend on
+// name, sorted.
+func (g *WorkspaceGraph) Dependents(name string) []string {
+	return append([]string{}, g.dependents[name]...)
+}
+
+// Cycles returns each group of workspaces which depend on one another
+// through a cycle of internal dependencies, each sorted by name, with the
+// groups sorted by their first name. It returns nil if the graph is acyclic.
+func (g *WorkspaceGraph) Cycles() [][]string {
+	// Tarjan's strongly connected components algorithm.
+	index := make(map[string]int, len(g.Workspaces))
+	lowlink := make(map[string]int, len(g.Workspaces))
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var connect func(name string)
+	connect = func(name string) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, dependency := range g.dependencies[name] {
+			if _, visited := index[dependency]; !visited {
+				connect(dependency)
+				if lowlink[dependency] < lowlink[name] {
+					lowlink[name] = lowlink[dependency]
+				}
+			} else if onStack[dependency] && index[dependency] < lowlink[name] {
+				lowlink[name] = index[dependency]
+			}
+		}
+
+		if lowlink[name] != index[name] {
+			return
+		}
+		var component []string
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			delete(onStack, member)
+			component = append(component, member)
+			if member == name {
+				break
+			}
+		}
+		// Self-dependencies are not edges, so a single workspace is never a cycle.
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, name := range g.Names() {
+		if _, visited := index[name]; !visited {
+			connect(name)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+// TopologicalOrder returns the names of every workspace such that each comes
+// after all of its dependencies. Workspaces whose order is not constrained
+// are sorted by name. It is an error for the graph to contain a cycle.
+func (g *WorkspaceGraph) TopologicalOrder() ([]string, error) {
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		return nil, fmt.Errorf("dependency cycle detected between %v", strings.Join(cycles[0], ", "))
+	}
+
+	remaining := make(map[string]int, len(g.Workspaces))
+	var ready []string
+	for _, name := range g.Names() {
+		remaining[name] = len(g.dependencies[name])
+		if remaining[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(g.Workspaces))
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+		for _, dependent := range g.dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = insertSorted(ready, dependent)
+			}
+		}
+	}
+	return order, nil
+}
+
+// TopologicalBatches returns the names of every workspace grouped into
+// batches which can run in parallel: each workspace is in the batch after the
+// last of its dependencies, and each batch is sorted by name. It is an error
+// for the graph to contain a cycle.
+func (g *WorkspaceGraph) TopologicalBatches() ([][]string, error) {
+	if cycles := g.Cycles(); len(cycles) > 0 {
+		return nil, fmt.Errorf("dependency cycle detected between %v", strings.Join(cycles[0], ", "))
+	}
+
+	remaining := make(map[string]int, len(g.Workspaces))
+	var batch []string
+	for _, name := range g.Names() {
+		remaining[name] = len(g.dependencies[name])
+		if remaining[name] == 0 {
+			batch = append(batch, name)
+		}
+	}
+
+	var batches [][]string
+	for len(batch) > 0 {
+		batches = append(batches, batch)
+		var next []string
+		for _, name := range batch {
+			for _, dependent := range g.dependents[name] {
+				remaining[dependent]--
+				if remaining[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		sort.Strings(next)
+		batch = next
+	}
+	return batches, nil
+}
+
+// TopologicalOrder returns the workspaces at rootpath grouped into batches
+// which can run in parallel while respecting internal dependencies, as
+// described by WorkspaceGraph.TopologicalBatches. It is an error for the
+// workspaces to depend on one another through a cycle.
+func (pm PackageManager) TopologicalOrder(rootpath fs.AbsolutePath) ([][]string, error) {
+	graph, err := pm.BuildWorkspaceGraph(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	return graph.TopologicalBatches()
+}
+
+// sortByDependencyDepth returns manifests ordered by the batch of
+// TopologicalBatches their workspace falls in, so that workspaces without
+// internal dependencies come first, and then by name and directory.
+func (pm PackageManager) sortByDependencyDepth(rootpath fs.AbsolutePath, manifests []string, synthesizeNames bool) ([]string, error) {
+	workspaces := make([]WorkspacePackage, len(manifests))
+	for i, manifest := range manifests {
+		workspace, err := readWorkspacePackage(rootpath, fs.AbsolutePathFromUpstream(filepath.Clean(manifest)), synthesizeNames)
+		if err != nil {
+			return nil, err
+		}
+		workspaces[i] = *workspace
+	}
+	graph, err := pm.buildWorkspaceGraph(rootpath, workspaces)
+	if err != nil {
+		return nil, err
+	}
+	batches, err := graph.TopologicalBatches()
+	if err != nil {
+		return nil, err
+	}
+	depth := make(map[string]int, len(workspaces))
+	for i, batch := range batches {
+		for _, name := range batch {
+			depth[name] = i
+		}
+	}
+
+	order := make([]int, len(workspaces))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := workspaces[order[i]], workspaces[order[j]]
+		if depth[a.Name] != depth[b.Name] {
+			return depth[a.Name] < depth[b.Name]
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Dir < b.Dir
+	})
+	sorted := make([]string, len(manifests))
+	for i, index := range order {
+		sorted[i] = manifests[index]
+	}
+	return sorted, nil
+}
+
+// insertSorted inserts value into the sorted slice values.
+func insertSorted(values []string, value string) []string {
+	i := sort.SearchStrings(values, value)
+	values = append(values, "")
+	copy(values[i+1:], values[i:])
+	values[i] = value
+	return values
+}
@@ -0,0 +1,149 @@
+package packagemanager
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestBuildWorkspaceGraph(t *testing.T) {
+	// web -> (ui, utils), docs -> ui, ui -> config, utils -> config, with
+	// utils -> config recorded only in the lockfile.
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":      `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"package-lock.json": diamondNpmLockfile,
+		"apps/web/package.json": `{"name": "web", "dependencies": {"ui": "*", "react": "^18.0.0"},
+			"devDependencies": {"utils": "*"}}`,
+		"apps/docs/package.json":       `{"name": "docs", "dependencies": {"ui": "*", "docs": "*"}}`,
+		"packages/ui/package.json":     `{"name": "ui", "dependencies": {"config": "*"}}`,
+		"packages/utils/package.json":  `{"name": "utils"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	graph, err := nodejsNpm.BuildWorkspaceGraph(rootPath)
+	assert.NilError(t, err, "BuildWorkspaceGraph")
+	assert.DeepEqual(t, graph.Names(), []string{"config", "docs", "ui", "utils", "web"})
+	assert.DeepEqual(t, graph.Dependencies("web"), []string{"ui", "utils"})
+	assert.DeepEqual(t, graph.Dependencies("utils"), []string{"config"})
+	assert.DeepEqual(t, graph.Dependencies("docs"), []string{"ui"})
+	assert.DeepEqual(t, graph.Dependents("config"), []string{"ui", "utils"})
+	assert.DeepEqual(t, graph.Dependents("ui"), []string{"docs", "web"})
+	assert.DeepEqual(t, graph.Dependents("web"), []string{})
+	assert.Assert(t, graph.Cycles() == nil)
+
+	order, err := graph.TopologicalOrder()
+	assert.NilError(t, err, "TopologicalOrder")
+	assert.DeepEqual(t, order, []string{"config", "ui", "docs", "utils", "web"})
+}
+
+func TestBuildWorkspaceGraph_Cycle(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":            `{"name": "root", "workspaces": ["packages/*"]}`,
+		"packages/a/package.json": `{"name": "a", "dependencies": {"b": "*"}}`,
+		"packages/b/package.json": `{"name": "b", "dependencies": {"c": "*"}}`,
+		"packages/c/package.json": `{"name": "c", "dependencies": {"b": "*"}}`,
+	})
+
+	graph, err := nodejsNpm.BuildWorkspaceGraph(rootPath)
+	assert.NilError(t, err, "BuildWorkspaceGraph without a lockfile")
+	assert.DeepEqual(t, graph.Cycles(), [][]string{{"b", "c"}})
+
+	_, err = graph.TopologicalOrder()
+	assert.ErrorContains(t, err, "dependency cycle detected between b, c")
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":        `{"name": "web", "dependencies": {"ui": "*", "utils": "*"}}`,
+		"apps/docs/package.json":       `{"name": "docs", "dependencies": {"ui": "*"}}`,
+		"apps/admin/package.json":      `{"name": "admin", "devDependencies": {"web": "*"}}`,
+		"packages/ui/package.json":     `{"name": "ui", "dependencies": {"config": "*"}}`,
+		"packages/utils/package.json":  `{"name": "utils"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	batches, err := nodejsNpm.TopologicalOrder(rootPath)
+	assert.NilError(t, err, "TopologicalOrder")
+	assert.DeepEqual(t, batches, [][]string{
+		{"config", "utils"},
+		{"ui"},
+		{"docs", "web"},
+		{"admin"},
+	})
+}
+
+func TestTopologicalOrder_Cycle(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":            `{"name": "root", "workspaces": ["packages/*"]}`,
+		"packages/a/package.json": `{"name": "a", "dependencies": {"b": "*"}}`,
+		"packages/b/package.json": `{"name": "b", "dependencies": {"a": "*"}}`,
+		"packages/c/package.json": `{"name": "c"}`,
+	})
+
+	_, err := nodejsNpm.TopologicalOrder(rootPath)
+	assert.ErrorContains(t, err, "dependency cycle detected between a, b")
+}
+
+func TestGetWorkspacesWithOpts_SortByDependencyDepth(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":        `{"name": "web", "dependencies": {"ui": "*", "utils": "*"}}`,
+		"apps/docs/package.json":       `{"name": "docs", "dependencies": {"ui": "*"}}`,
+		"apps/admin/package.json":      `{"name": "admin", "devDependencies": {"web": "*"}}`,
+		"packages/ui/package.json":     `{"name": "ui", "dependencies": {"config": "*"}}`,
+		"packages/utils/package.json":  `{"name": "utils"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+	want := []string{
+		"packages/config/package.json",
+		"packages/utils/package.json",
+		"packages/ui/package.json",
+		"apps/docs/package.json",
+		"apps/web/package.json",
+		"apps/admin/package.json",
+	}
+
+	manifests, err := nodejsNpm.GetWorkspacesWithOpts(rootPath, WorkspaceOpts{SortByDependencyDepth: true})
+	assert.NilError(t, err, "GetWorkspacesWithOpts")
+	got := make([]string, len(manifests))
+	for i, manifest := range manifests {
+		rel, err := filepath.Rel(rootPath.ToStringDuringMigration(), manifest)
+		assert.NilError(t, err, "Rel")
+		got[i] = filepath.ToSlash(rel)
+	}
+	assert.DeepEqual(t, got, want)
+
+	workspaces, err := nodejsNpm.GetWorkspacePackagesWithOpts(rootPath, WorkspaceOpts{SortByDependencyDepth: true})
+	assert.NilError(t, err, "GetWorkspacePackagesWithOpts")
+	names := make([]string, len(workspaces))
+	for i, workspace := range workspaces {
+		names[i] = workspace.Name
+	}
+	assert.DeepEqual(t, names, []string{"config", "utils", "ui", "docs", "web", "admin"})
+}
+
+func TestBuildWorkspaceGraph_ViewsAgree(t *testing.T) {
+	// yarn.lock cannot be read, so every view sees only the manifest edges
+	// web -> ui -> config.
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"yarn.lock":                    "# yarn lockfile v1\n",
+		"apps/web/package.json":        `{"name": "web", "dependencies": {"ui": "*"}}`,
+		"packages/ui/package.json":     `{"name": "ui", "optionalDependencies": {"config": "*"}}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	closure, err := nodejsYarn.InternalDependencyClosure(rootPath, "web")
+	assert.NilError(t, err, "InternalDependencyClosure")
+	assert.DeepEqual(t, closure, []string{"config", "ui"})
+
+	affected, err := nodejsYarn.AffectedWorkspaces(rootPath, []string{"packages/config/index.js"})
+	assert.NilError(t, err, "AffectedWorkspaces")
+	assert.DeepEqual(t, affected, []string{"config", "ui", "web"})
+
+	batches, err := nodejsYarn.TopologicalOrder(rootPath)
+	assert.NilError(t, err, "TopologicalOrder")
+	assert.DeepEqual(t, batches, [][]string{{"config"}, {"ui"}, {"web"}})
+}
@@ -0,0 +1,86 @@
+package packagemanager
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+)
+
+// workspaceIncludeFile lists directory globs, one per line and relative to the
+// repository root, which are searched for workspaces even though the package
+// manager's workspace ignores exclude them. Blank lines and lines starting
+// with `#` are skipped.
+const workspaceIncludeFile = ".turbo/workspace-include"
+
+// readWorkspaceIncludes returns the globs in the workspace include file at
+// rootpath, or nil if there is no such file.
+func readWorkspaceIncludes(rootpath fs.AbsolutePath) ([]string, error) {
+	includePath := rootpath.Join(filepath.FromSlash(workspaceIncludeFile))
+	if !includePath.FileExists() {
+		return nil, nil
+	}
+	contents, err := includePath.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", workspaceIncludeFile, err)
+	}
+
+	var includes []string
+	for _, line := range strings.Split(string(normalizeLineEndings(contents)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if filepath.IsAbs(line) || path.IsAbs(filepath.ToSlash(line)) {
+			return nil, fmt.Errorf("%v: invalid glob %q: globs must be relative to the repository root", workspaceIncludeFile, line)
+		}
+		includes = append(includes, strings.TrimRight(line, "/"))
+	}
+	return includes, nil
+}
+
+// reincludeWorkspaces adds to workspaces the manifests matching justJsons
+// which lie within the directories matched by includes, disregarding
+// workspace ignores. Ignores do not apply anywhere within a re-included
+// directory.
+func reincludeWorkspaces(rootpath fs.AbsolutePath, workspaces []string, justJsons []string, includes []string, matcher WorkspaceMatcher) ([]string, error) {
+	searches := make([]string, 0, 2*len(includes))
+	for _, include := range includes {
+		searches = append(searches, filepath.Join(include, "package.json"), filepath.Join(include, "**", "package.json"))
+	}
+	candidates, err := matcher(rootpath.ToStringDuringMigration(), searches, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	workspacePatterns := make([]string, len(justJsons))
+	for i, justJson := range justJsons {
+		workspacePatterns[i] = path.Clean(filepath.ToSlash(justJson))
+	}
+
+	seen := make(util.Set)
+	for _, workspace := range workspaces {
+		seen.Add(workspace)
+	}
+	for _, candidate := range candidates {
+		if seen.Includes(candidate) {
+			continue
+		}
+		rel, err := filepath.Rel(rootpath.ToStringDuringMigration(), candidate)
+		if err != nil {
+			return nil, err
+		}
+		matched, err := matchesAny(workspacePatterns, filepath.ToSlash(rel))
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			seen.Add(candidate)
+			workspaces = append(workspaces, candidate)
+		}
+	}
+	return workspaces, nil
+}
@@ -0,0 +1,36 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_GetWorkspaces_WorkspaceInclude(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                                     `{"name": "root", "workspaces": ["packages/**"]}`,
+		"packages/ui/package.json":                         `{"name": "ui"}`,
+		"packages/ui/node_modules/react/package.json":      `{"name": "react"}`,
+		"packages/vendor/node_modules/forked/package.json": `{"name": "forked"}`,
+		"apps/node_modules/other/package.json":             `{"name": "other"}`,
+		".turbo/workspace-include":                         "# vendored packages we patch locally\r\npackages/vendor/node_modules/*\r\n\r\napps/node_modules/*\r\n",
+	})
+
+	workspaces, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.NilError(t, err, "GetWorkspaces")
+	assert.DeepEqual(t, relativeWorkspaces(t, rootPath, workspaces), []string{
+		"packages/ui/package.json",
+		"packages/vendor/node_modules/forked/package.json",
+	})
+}
+
+func Test_GetWorkspaces_WorkspaceIncludeAbsolute(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["packages/*"]}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+		".turbo/workspace-include": "/packages/ui\n",
+	})
+
+	_, err := nodejsNpm.GetWorkspaces(rootPath)
+	assert.ErrorContains(t, err, "globs must be relative to the repository root")
+}
@@ -0,0 +1,318 @@
+package packagemanager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/doublestar"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/xxhash"
+)
+
+// WorkspacePackage is a discovered workspace along with its parsed manifest.
+type WorkspacePackage struct {
+	// The name of the workspace, from its package.json.
+	Name string
+
+	// The slash-separated directory of the workspace relative to the repository root.
+	Dir string
+
+	// The location of the workspace's package.json.
+	ManifestPath fs.AbsolutePath
+
+	// The parsed contents of the workspace's package.json.
+	Manifest *fs.PackageJSON
+}
+
+// SyntheticNamePrefix begins the name given to a workspace without one when
+// WorkspaceOpts.SynthesizeNames is set. It cannot begin a valid package name.
+const SyntheticNamePrefix = "<unnamed>/"
+
+// GetWorkspacePackages discovers the workspaces in the repository and parses
+// each of their manifests. Workspaces are sorted by directory. A manifest
+// without a name is an error.
+func (pm PackageManager) GetWorkspacePackages(rootpath fs.AbsolutePath) ([]WorkspacePackage, error) {
+	return pm.GetWorkspacePackagesWithOpts(rootpath, WorkspaceOpts{})
+}
+
+// GetWorkspacePackagesWithOpts is GetWorkspacePackages with workspaces
+// discovered according to opts. With opts.SortByDependencyDepth, workspaces
+// are kept in that order rather than sorted by directory.
+func (pm PackageManager) GetWorkspacePackagesWithOpts(rootpath fs.AbsolutePath, opts WorkspaceOpts) ([]WorkspacePackage, error) {
+	manifests, err := pm.GetWorkspacesWithOpts(rootpath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]WorkspacePackage, len(manifests))
+	for i, manifest := range manifests {
+		workspace, err := readWorkspacePackage(rootpath, fs.AbsolutePathFromUpstream(filepath.Clean(manifest)), opts.SynthesizeNames)
+		if err != nil {
+			return nil, err
+		}
+		workspaces[i] = *workspace
+	}
+
+	if opts.SortByDependencyDepth {
+		return workspaces, nil
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Dir < workspaces[j].Dir
+	})
+	return workspaces, nil
+}
+
+// FindDuplicateWorkspaceNames returns each workspace name declared by more
+// than one workspace manifest, mapped to the paths of those manifests sorted
+// by workspace directory. Names which are unique are omitted.
+func (pm PackageManager) FindDuplicateWorkspaceNames(rootpath fs.AbsolutePath) (map[string][]string, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make(map[string][]string)
+	for _, workspace := range workspaces {
+		manifests[workspace.Name] = append(manifests[workspace.Name], workspace.ManifestPath.ToStringDuringMigration())
+	}
+	duplicates := make(map[string][]string)
+	for name, paths := range manifests {
+		if len(paths) > 1 {
+			duplicates[name] = paths
+		}
+	}
+	return duplicates, nil
+}
+
+// GetWorkspacesByVisibility returns the workspaces whose manifests set
+// `"private": true` if includePrivate is set, and otherwise the publishable
+// workspaces, whose manifests set `"private": false` or omit it. Workspaces are
+// sorted by directory.
+func (pm PackageManager) GetWorkspacesByVisibility(rootpath fs.AbsolutePath, includePrivate bool) ([]WorkspacePackage, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []WorkspacePackage{}
+	for _, workspace := range workspaces {
+		if workspace.Manifest.Private == includePrivate {
+			filtered = append(filtered, workspace)
+		}
+	}
+	return filtered, nil
+}
+
+// UnmatchedWorkspaces is the GroupWorkspacesByGlob key for workspaces which no
+// workspace glob matches, such as those listed by TURBO_WORKSPACES or
+// re-included by .turbo/workspace-include.
+const UnmatchedWorkspaces = ""
+
+// GroupWorkspacesByGlob returns the workspaces keyed by the workspace glob, as
+// declared, which matches their directory, or by UnmatchedWorkspaces if there
+// is none. A workspace matched by several overlapping globs is attributed only
+// to the first one declared. Workspaces are sorted by directory within each
+// group.
+func (pm PackageManager) GroupWorkspacesByGlob(rootpath fs.AbsolutePath) (map[string][]WorkspacePackage, error) {
+	globs, err := pm.workspaceGlobs(rootpath)
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]WorkspacePackage)
+	for _, workspace := range workspaces {
+		glob, err := matchingGlob(globs, workspace.Dir)
+		if err != nil {
+			return nil, err
+		}
+		groups[glob] = append(groups[glob], workspace)
+	}
+	return groups, nil
+}
+
+// matchingGlob returns the first of globs which matches the slash-separated
+// directory dir, or UnmatchedWorkspaces if none does.
+func matchingGlob(globs []string, dir string) (string, error) {
+	for _, glob := range globs {
+		if strings.HasPrefix(glob, "!") {
+			continue
+		}
+		matched, err := doublestar.Match(path.Clean(filepath.ToSlash(glob)), dir)
+		if err != nil {
+			return "", fmt.Errorf("invalid workspace glob %q: %w", glob, err)
+		}
+		if matched {
+			return glob, nil
+		}
+	}
+	return UnmatchedWorkspaces, nil
+}
+
+// ErrNoOwningWorkspace is matched by the error returned from
+// FindOwningWorkspace for a file outside every workspace.
+var ErrNoOwningWorkspace = errors.New("file is not within any workspace")
+
+// FindOwningWorkspace returns the workspace whose directory is the closest
+// ancestor of filePath. Files outside every workspace, such as root
+// configuration files, produce an error matching ErrNoOwningWorkspace.
+func (pm PackageManager) FindOwningWorkspace(rootpath fs.AbsolutePath, filePath fs.AbsolutePath) (*WorkspacePackage, error) {
+	relativePath, err := filepath.Rel(rootpath.ToStringDuringMigration(), filePath.ToStringDuringMigration())
+	if err != nil {
+		return nil, err
+	}
+	relativePath = filepath.ToSlash(relativePath)
+
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := owningWorkspace(workspaces, relativePath)
+	if owner == nil {
+		return nil, fmt.Errorf("%v: %w", relativePath, ErrNoOwningWorkspace)
+	}
+	return owner, nil
+}
+
+// owningWorkspace returns the workspace whose directory is the closest
+// ancestor of the slash-separated relativePath, or nil if there is none.
+func owningWorkspace(workspaces []WorkspacePackage, relativePath string) *WorkspacePackage {
+	var owner *WorkspacePackage
+	for i, workspace := range workspaces {
+		if relativePath != workspace.Dir && !strings.HasPrefix(relativePath, workspace.Dir+"/") {
+			continue
+		}
+		if owner == nil || len(workspace.Dir) > len(owner.Dir) {
+			owner = &workspaces[i]
+		}
+	}
+	return owner
+}
+
+// readWorkspacePackage parses the workspace manifest at manifestPath. A
+// manifest without a name is an error unless synthesizeName is set, in which
+// case the workspace is named after its directory.
+func readWorkspacePackage(rootpath fs.AbsolutePath, manifestPath fs.AbsolutePath, synthesizeName bool) (*WorkspacePackage, error) {
+	manifest, err := fs.ReadPackageJSON(manifestPath.ToStringDuringMigration())
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v: %w", manifestPath, err)
+	}
+	relativeManifestPath, err := filepath.Rel(rootpath.ToStringDuringMigration(), manifestPath.ToStringDuringMigration())
+	if err != nil {
+		return nil, err
+	}
+	dir := WorkspaceDir(relativeManifestPath)
+	name := manifest.Name
+	if name == "" {
+		if !synthesizeName {
+			return nil, fmt.Errorf("%v: workspace has no name", manifestPath)
+		}
+		name = SyntheticNamePrefix + dir
+	}
+	return &WorkspacePackage{
+		Name:         name,
+		Dir:          dir,
+		ManifestPath: manifestPath,
+		Manifest:     manifest,
+	}, nil
+}
+
+// WorkspaceDir returns the slash-separated directory containing a workspace
+// manifest, given the manifest's path relative to the repository root. The
+// root manifest's directory is ".".
+func WorkspaceDir(manifestPath string) string {
+	return filepath.ToSlash(filepath.Dir(filepath.Clean(manifestPath)))
+}
+
+// WorkspaceName returns the name declared by the workspace manifest at
+// manifestPath, relative to rootpath.
+func WorkspaceName(rootpath fs.AbsolutePath, manifestPath string) (string, error) {
+	workspace, err := readWorkspacePackage(rootpath, rootpath.Join(manifestPath), false)
+	if err != nil {
+		return "", err
+	}
+	return workspace.Name, nil
+}
+
+// GetWorkspaceScripts returns the names of the scripts defined by each
+// workspace, keyed by workspace name. Workspaces without scripts map to an
+// empty list. It is an error for two workspaces to share a name; see
+// FindDuplicateWorkspaceNames.
+func (pm PackageManager) GetWorkspaceScripts(rootpath fs.AbsolutePath) (map[string][]string, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make(map[string][]string, len(workspaces))
+	dirs := make(map[string]string, len(workspaces))
+	for _, workspace := range workspaces {
+		if dir, ok := dirs[workspace.Name]; ok {
+			return nil, fmt.Errorf("workspace name %q is declared by both %v and %v", workspace.Name, dir, workspace.Dir)
+		}
+		dirs[workspace.Name] = workspace.Dir
+		names := make([]string, 0, len(workspace.Manifest.Scripts))
+		for name := range workspace.Manifest.Scripts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		scripts[workspace.Name] = names
+	}
+	return scripts, nil
+}
+
+// workspaceManifestHashInput is the subset of a workspace manifest which
+// contributes to HashWorkspaceManifests.
+type workspaceManifestHashInput struct {
+	Dir                  string            `json:"dir"`
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// HashWorkspaceManifests returns a hash of the name, version, and dependencies
+// of every workspace manifest. The hash does not depend on the order in which
+// workspaces are discovered or in which fields and keys appear in each manifest.
+func (pm PackageManager) HashWorkspaceManifests(rootpath fs.AbsolutePath) (string, error) {
+	workspaces, err := pm.GetWorkspacePackages(rootpath)
+	if err != nil {
+		return "", err
+	}
+
+	inputs := make([]workspaceManifestHashInput, len(workspaces))
+	for i, workspace := range workspaces {
+		inputs[i] = workspaceManifestHashInput{
+			Dir:                  workspace.Dir,
+			Name:                 workspace.Name,
+			Version:              workspace.Manifest.Version,
+			Dependencies:         workspace.Manifest.Dependencies,
+			DevDependencies:      workspace.Manifest.DevDependencies,
+			OptionalDependencies: workspace.Manifest.OptionalDependencies,
+			PeerDependencies:     workspace.Manifest.PeerDependencies,
+		}
+	}
+
+	// encoding/json sorts map keys, and workspaces are sorted by directory.
+	canonical, err := json.Marshal(inputs)
+	if err != nil {
+		return "", err
+	}
+	hash := xxhash.New()
+	if _, err := hash.Write(canonical); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,259 @@
+package packagemanager
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetWorkspacePackages(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":    `{"name": "web", "version": "1.0.0"}`,
+		"packages/ui/package.json": `{"name": "ui", "version": "0.1.0"}`,
+	})
+
+	workspaces, err := nodejsNpm.GetWorkspacePackages(rootPath)
+	assert.NilError(t, err, "GetWorkspacePackages")
+	assert.Equal(t, len(workspaces), 2)
+	assert.Equal(t, workspaces[0].Name, "web")
+	assert.Equal(t, workspaces[0].Dir, "apps/web")
+	assert.Equal(t, workspaces[0].ManifestPath, rootPath.Join("apps", "web", "package.json"))
+	assert.Equal(t, workspaces[0].Manifest.Version, "1.0.0")
+	assert.Equal(t, workspaces[1].Name, "ui")
+	assert.Equal(t, workspaces[1].Dir, "packages/ui")
+}
+
+func TestGetWorkspacePackages_Nameless(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["packages/*"]}`,
+		"packages/ui/package.json": `{"version": "0.1.0"}`,
+	})
+
+	_, err := nodejsNpm.GetWorkspacePackages(rootPath)
+	assert.ErrorContains(t, err, "workspace has no name")
+
+	workspaces, err := nodejsNpm.GetWorkspacePackagesWithOpts(rootPath, WorkspaceOpts{SynthesizeNames: true})
+	assert.NilError(t, err, "GetWorkspacePackagesWithOpts")
+	assert.Equal(t, len(workspaces), 1)
+	assert.Equal(t, workspaces[0].Name, "<unnamed>/packages/ui")
+	assert.Equal(t, workspaces[0].Manifest.Version, "0.1.0")
+}
+
+func TestGetWorkspaceScripts(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":    `{"name": "web", "scripts": {"dev": "next dev", "build": "next build", "lint": "eslint ."}}`,
+		"packages/ui/package.json": `{"name": "ui"}`,
+	})
+
+	scripts, err := nodejsNpm.GetWorkspaceScripts(rootPath)
+	assert.NilError(t, err, "GetWorkspaceScripts")
+	assert.DeepEqual(t, scripts, map[string][]string{
+		"web": {"build", "dev", "lint"},
+		"ui":  {},
+	})
+}
+
+func TestGetWorkspaceScripts_DuplicateName(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/ui/package.json":     `{"name": "ui", "scripts": {"dev": "vite"}}`,
+		"packages/ui/package.json": `{"name": "ui", "scripts": {"build": "tsc"}}`,
+	})
+
+	_, err := nodejsNpm.GetWorkspaceScripts(rootPath)
+	assert.ErrorContains(t, err, `workspace name "ui" is declared by both apps/ui and packages/ui`)
+}
+
+func TestWorkspaceDir(t *testing.T) {
+	tests := []struct {
+		manifestPath string
+		want         string
+	}{
+		{manifestPath: "package.json", want: "."},
+		{manifestPath: "./package.json", want: "."},
+		{manifestPath: "packages/foo/package.json", want: "packages/foo"},
+		{manifestPath: filepath.Join("apps", "nested", "web", "package.json"), want: "apps/nested/web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.manifestPath, func(t *testing.T) {
+			assert.Equal(t, WorkspaceDir(tt.manifestPath), tt.want)
+		})
+	}
+}
+
+func TestWorkspaceName(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":              `{"name": "root"}`,
+		"packages/foo/package.json": `{"name": "@scope/foo"}`,
+	})
+
+	name, err := WorkspaceName(rootPath, "packages/foo/package.json")
+	assert.NilError(t, err, "WorkspaceName")
+	assert.Equal(t, name, "@scope/foo")
+
+	name, err = WorkspaceName(rootPath, "package.json")
+	assert.NilError(t, err, "WorkspaceName")
+	assert.Equal(t, name, "root")
+}
+
+func TestHashWorkspaceManifests(t *testing.T) {
+	hash := func(files map[string]string) string {
+		t.Helper()
+		hash, err := nodejsNpm.HashWorkspaceManifests(setupFixture(t, files))
+		assert.NilError(t, err, "HashWorkspaceManifests")
+		return hash
+	}
+
+	base := hash(map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":    `{"name": "web", "version": "1.0.0", "dependencies": {"ui": "*", "next": "12.0.0"}}`,
+		"packages/ui/package.json": `{"name": "ui", "version": "0.1.0"}`,
+	})
+	reordered := hash(map[string]string{
+		"package.json":             `{"workspaces": ["packages/*", "apps/*"], "name": "root"}`,
+		"apps/web/package.json":    `{"dependencies": {"next": "12.0.0", "ui": "*"}, "version": "1.0.0", "name": "web", "scripts": {"dev": "next dev"}}`,
+		"packages/ui/package.json": `{"version": "0.1.0", "name": "ui"}`,
+	})
+	changed := hash(map[string]string{
+		"package.json":             `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":    `{"name": "web", "version": "1.0.0", "dependencies": {"ui": "*", "next": "12.1.0"}}`,
+		"packages/ui/package.json": `{"name": "ui", "version": "0.1.0"}`,
+	})
+
+	assert.Equal(t, base, reordered)
+	assert.Assert(t, base != changed, "expected a dependency change to change the hash")
+}
+
+func TestFindDuplicateWorkspaceNames(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":        `{"name": "web"}`,
+		"apps/ui-legacy/package.json":  `{"name": "ui"}`,
+		"packages/ui/package.json":     `{"name": "ui"}`,
+		"packages/config/package.json": `{"name": "config"}`,
+	})
+
+	duplicates, err := nodejsNpm.FindDuplicateWorkspaceNames(rootPath)
+	assert.NilError(t, err, "FindDuplicateWorkspaceNames")
+	assert.DeepEqual(t, duplicates, map[string][]string{
+		"ui": {
+			rootPath.Join("apps", "ui-legacy", "package.json").ToStringDuringMigration(),
+			rootPath.Join("packages", "ui", "package.json").ToStringDuringMigration(),
+		},
+	})
+
+	rootPath = setupFixture(t, map[string]string{
+		"package.json":          `{"name": "root", "workspaces": ["apps/*"]}`,
+		"apps/web/package.json": `{"name": "web"}`,
+	})
+	duplicates, err = nodejsNpm.FindDuplicateWorkspaceNames(rootPath)
+	assert.NilError(t, err, "FindDuplicateWorkspaceNames")
+	assert.Equal(t, len(duplicates), 0)
+}
+
+func TestGetWorkspacesByVisibility(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                 `{"name": "root", "workspaces": ["apps/*", "packages/*"]}`,
+		"apps/web/package.json":        `{"name": "web", "private": true}`,
+		"packages/ui/package.json":     `{"name": "ui", "private": false}`,
+		"packages/utils/package.json":  `{"name": "utils"}`,
+		"packages/config/package.json": `{"name": "config", "private": true}`,
+	})
+
+	names := func(workspaces []WorkspacePackage) []string {
+		names := make([]string, len(workspaces))
+		for i, workspace := range workspaces {
+			names[i] = workspace.Name
+		}
+		return names
+	}
+
+	private, err := nodejsNpm.GetWorkspacesByVisibility(rootPath, true)
+	assert.NilError(t, err, "GetWorkspacesByVisibility")
+	assert.DeepEqual(t, names(private), []string{"web", "config"})
+
+	public, err := nodejsNpm.GetWorkspacesByVisibility(rootPath, false)
+	assert.NilError(t, err, "GetWorkspacesByVisibility")
+	assert.DeepEqual(t, names(public), []string{"ui", "utils"})
+}
+
+func TestGroupWorkspacesByGlob(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                `{"name": "root", "workspaces": ["apps/*", "packages/*", "packages/**"]}`,
+		"apps/web/package.json":       `{"name": "web"}`,
+		"apps/docs/package.json":      `{"name": "docs"}`,
+		"packages/ui/package.json":    `{"name": "ui"}`,
+		"packages/lib/a/package.json": `{"name": "a"}`,
+	})
+
+	groups, err := nodejsNpm.GroupWorkspacesByGlob(rootPath)
+	assert.NilError(t, err, "GroupWorkspacesByGlob")
+	dirs := make(map[string][]string)
+	for glob, workspaces := range groups {
+		for _, workspace := range workspaces {
+			dirs[glob] = append(dirs[glob], workspace.Dir)
+		}
+	}
+	assert.DeepEqual(t, dirs, map[string][]string{
+		"apps/*":      {"apps/docs", "apps/web"},
+		"packages/*":  {"packages/ui"},
+		"packages/**": {"packages/lib/a"},
+	})
+}
+
+func TestGroupWorkspacesByGlob_Unmatched(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                `{"name": "root", "workspaces": ["apps/*"]}`,
+		"apps/web/package.json":       `{"name": "web"}`,
+		"tools/internal/package.json": `{"name": "internal"}`,
+	})
+	t.Setenv("TURBO_WORKSPACES", "apps/web,tools/internal")
+
+	groups, err := nodejsNpm.GroupWorkspacesByGlob(rootPath)
+	assert.NilError(t, err, "GroupWorkspacesByGlob")
+	assert.Equal(t, len(groups["apps/*"]), 1)
+	assert.Equal(t, len(groups[UnmatchedWorkspaces]), 1)
+	assert.Equal(t, groups[UnmatchedWorkspaces][0].Dir, "tools/internal")
+}
+
+func TestFindOwningWorkspace(t *testing.T) {
+	rootPath := setupFixture(t, map[string]string{
+		"package.json":                         `{"name": "root", "workspaces": ["apps/*", "apps/web/plugins/*", "packages/*"]}`,
+		"turbo.json":                           `{}`,
+		"apps/web/package.json":                `{"name": "web"}`,
+		"apps/web/src/pages/index.tsx":         "",
+		"apps/web/plugins/seo/package.json":    `{"name": "seo"}`,
+		"apps/web/plugins/seo/src/index.ts":    "",
+		"packages/ui/package.json":             `{"name": "ui"}`,
+		"packages/ui-kit/package.json":         `{"name": "ui-kit"}`,
+		"packages/ui-kit/src/button/button.ts": "",
+	})
+
+	tests := []struct {
+		filePath string
+		want     string
+	}{
+		{filePath: "apps/web/src/pages/index.tsx", want: "web"},
+		{filePath: "apps/web/package.json", want: "web"},
+		{filePath: "apps/web/plugins/seo/src/index.ts", want: "seo"},
+		{filePath: "packages/ui-kit/src/button/button.ts", want: "ui-kit"},
+		{filePath: "apps/web", want: "web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filePath, func(t *testing.T) {
+			workspace, err := nodejsNpm.FindOwningWorkspace(rootPath, rootPath.Join(filepath.FromSlash(tt.filePath)))
+			assert.NilError(t, err, "FindOwningWorkspace")
+			assert.Equal(t, workspace.Name, tt.want)
+		})
+	}
+
+	for _, filePath := range []string{"turbo.json", "apps/README.md"} {
+		t.Run(filePath, func(t *testing.T) {
+			_, err := nodejsNpm.FindOwningWorkspace(rootPath, rootPath.Join(filepath.FromSlash(filePath)))
+			assert.ErrorIs(t, err, ErrNoOwningWorkspace)
+		})
+	}
+}
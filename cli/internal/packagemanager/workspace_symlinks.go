@@ -0,0 +1,79 @@
+package packagemanager
+
+import (
+	iofs "io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/vercel/turborepo/cli/internal/doublestar"
+	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+)
+
+// withLinkedWorkspaces returns manifests along with the package.json of every
+// symlinked directory matched by one of globs and none of ignores, which
+// matchers do not descend into. Directories below a symlink are not searched.
+func withLinkedWorkspaces(rootpath fs.AbsolutePath, manifests []string, globs []string, ignores []string) ([]string, error) {
+	root := rootpath.ToStringDuringMigration()
+	dirGlobs, err := compileScanPatterns(root, globs, "")
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := compileScanPatterns(root, ignores, "**")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(util.Set)
+	for _, manifest := range manifests {
+		seen.Add(manifest)
+	}
+	fsys := os.DirFS(root)
+	for _, dirGlob := range dirGlobs {
+		err := doublestar.GlobWalk(fsys, dirGlob, func(p string, d iofs.DirEntry) error {
+			if d.Type()&iofs.ModeSymlink == 0 {
+				return nil
+			}
+			manifest := path.Join(p, "package.json")
+			if excluded, err := matchesAny(excludes, manifest); err != nil || excluded {
+				return err
+			}
+			absolute := filepath.Join(root, filepath.FromSlash(manifest))
+			if seen.Includes(absolute) || !fs.FileExists(absolute) {
+				return nil
+			}
+			seen.Add(absolute)
+			manifests = append(manifests, absolute)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifests, nil
+}
+
+// resolveWorkspaceSymlinks replaces each of manifests with its real path,
+// keeping only the first of any which resolve to the same file. Real paths
+// within rootpath remain below rootpath as given, even if it is itself a
+// symlink.
+func resolveWorkspaceSymlinks(rootpath fs.AbsolutePath, manifests []string) []string {
+	root := rootpath.ToStringDuringMigration()
+	realRoot := realpathOrSelf(root)
+	seen := make(util.Set)
+	resolved := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		realpath := realpathOrSelf(manifest)
+		if rel, err := filepath.Rel(realRoot, realpath); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			realpath = filepath.Join(root, rel)
+		}
+		if seen.Includes(realpath) {
+			continue
+		}
+		seen.Add(realpath)
+		resolved = append(resolved, realpath)
+	}
+	return resolved
+}
@@ -1,13 +1,14 @@
 package packagemanager
 
 import (
+	"bytes"
 	"fmt"
-	"os/exec"
 	"path/filepath"
-	"strings"
 
 	"github.com/Masterminds/semver"
 	"github.com/vercel/turborepo/cli/internal/fs"
+	"github.com/vercel/turborepo/cli/internal/util"
+	"gopkg.in/yaml.v3"
 )
 
 var nodejsYarn = PackageManager{
@@ -17,16 +18,54 @@ var nodejsYarn = PackageManager{
 	Specfile:   "package.json",
 	Lockfile:   "yarn.lock",
 	PackageDir: "node_modules",
+	DocsURL:    "https://classic.yarnpkg.com/en/docs/workspaces",
 
-	getWorkspaceGlobs: func(rootpath fs.AbsolutePath) ([]string, error) {
-		pkg, err := fs.ReadPackageJSON(rootpath.Join("package.json").ToStringDuringMigration())
+	// yarn 1 introduced workspaces.
+	MinSupportedVersion: "1.0.0",
+
+	// Yarn classic has no check-only mode, so this performs a frozen install
+	// that fails when yarn.lock needs updating.
+	lockfileCheckArgs: []string{"install", "--frozen-lockfile"},
+
+	ciInstallArgs: []string{"install", "--frozen-lockfile"},
+
+	installNoSaveArgs: []string{"install", "--pure-lockfile"},
+
+	globalBinArgs: []string{"global", "bin"},
+
+	addArgs:    []string{"add"},
+	addDevFlag: "-D",
+
+	removeArgs: []string{"remove"},
+
+	whyArgs: []string{"why"},
+
+	lockfileSignature: yarnClassicLockfileSignature,
+
+	// The only version of the classic format is named in its header comment.
+	lockfileVersion: func(head []byte) (string, bool) {
+		return "1", yarnClassicLockfileSignature(head)
+	},
+
+	lockfileFormats: []versionedLockfileFormat{
+		{since: "0.0.0", format: LockfileFormatYarnV1},
+	},
+
+	nodeLinker: Hoisted,
+
+	hasWorkspaces: hasPackageJSONWorkspaces,
+
+	readsWorkspaceField: true,
+
+	getWorkspaceGlobs: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
+		workspaces, err := readPackageJSONWorkspaces(pm, rootpath)
 		if err != nil {
 			return nil, fmt.Errorf("package.json: %w", err)
 		}
-		if len(pkg.Workspaces) == 0 {
-			return nil, fmt.Errorf("package.json: no workspaces found. Turborepo requires Yarn workspaces to be defined in the root package.json")
+		if len(workspaces) == 0 {
+			return nil, fmt.Errorf("package.json: no workspaces found. Turborepo requires Yarn workspaces to be defined in the root package.json. See %v", pm.DocsURL)
 		}
-		return pkg.Workspaces, nil
+		return workspaces, nil
 	},
 
 	getWorkspaceIgnores: func(pm PackageManager, rootpath fs.AbsolutePath) ([]string, error) {
@@ -37,7 +76,7 @@ var nodejsYarn = PackageManager{
 		// For example: `apps/*/node_modules/**/+(package.json|yarn.json)`
 		// The `extglob` `+(package.json|yarn.json)` (from micromatch) after node_modules/** is redundant.
 
-		globs, err := pm.getWorkspaceGlobs(rootpath)
+		globs, err := pm.workspaceGlobs(rootpath)
 		if err != nil {
 			return nil, err
 		}
@@ -45,7 +84,7 @@ var nodejsYarn = PackageManager{
 		ignores := make([]string, len(globs))
 
 		for i, glob := range globs {
-			ignores[i] = filepath.Join(glob, "/node_modules/**")
+			ignores[i] = filepath.Join(glob, pm.PackageDir, "**")
 		}
 
 		return ignores, nil
@@ -69,23 +108,92 @@ var nodejsYarn = PackageManager{
 		return c.Check(v), nil
 	},
 
-	// Detect for yarn needs to identify which version of yarn is running on the system.
+	// Detect for yarn needs to tell classic apart from berry, which share
+	// yarn.lock. Only files in the project directory are consulted, so that
+	// detection works before yarn is installed, e.g. in a CI pre-install step.
 	detect: func(projectDirectory fs.AbsolutePath, packageManager *PackageManager) (bool, error) {
 		specfileExists := projectDirectory.Join(packageManager.Specfile).FileExists()
-		lockfileExists := projectDirectory.Join(packageManager.Lockfile).FileExists()
+		lockfileExists := packageManager.LockfilePath(projectDirectory).FileExists()
 
 		// Short-circuit, definitely not Yarn.
 		if !specfileExists || !lockfileExists {
 			return false, nil
 		}
 
-		cmd := exec.Command("yarn", "--version")
-		cmd.Dir = projectDirectory.ToString()
-		out, err := cmd.Output()
-		if err != nil {
-			return false, fmt.Errorf("could not detect yarn version: %w", err)
-		}
-
-		return packageManager.Matches(packageManager.Slug, strings.TrimSpace(string(out)))
+		// Without any signal of berry, the project is classic.
+		return detectYarnVariant(projectDirectory) != yarnVariantBerry, nil
 	},
 }
+
+type yarnVariant int
+
+const (
+	yarnVariantUnknown yarnVariant = iota
+	yarnVariantClassic
+	yarnVariantBerry
+)
+
+// detectYarnVariant distinguishes berry from classic using only files in the
+// project directory. Any of a `.yarnrc.yml` with `yarnPath`, a `.pnp.cjs`, or a
+// `.yarn/releases/` directory indicates berry. Otherwise the content of
+// yarn.lock decides, and failing that a classic `.yarnrc` indicates classic
+// and a `.yarnrc.yml`, which only berry reads, indicates berry. If none
+// applies the variant is unknown.
+func detectYarnVariant(projectDirectory fs.AbsolutePath) yarnVariant {
+	if yarnPathSetting(projectDirectory) != "" {
+		return yarnVariantBerry
+	}
+	if projectDirectory.Join(".pnp.cjs").FileExists() || projectDirectory.Join(".yarn", "releases").DirExists() {
+		return yarnVariantBerry
+	}
+	// A fresh install rewrites yarn.lock, so an explicitly pinned berry
+	// release above wins over a classic lockfile awaiting migration.
+	if variant := yarnLockfileVariant(projectDirectory); variant != yarnVariantUnknown {
+		return variant
+	}
+	if projectDirectory.Join(".yarnrc").FileExists() {
+		return yarnVariantClassic
+	}
+	if projectDirectory.Join(".yarnrc.yml").FileExists() {
+		return yarnVariantBerry
+	}
+	return yarnVariantUnknown
+}
+
+// yarnPathSetting returns the yarnPath set by the .yarnrc.yml in
+// projectDirectory, or "" if it is unset or the file cannot be read. Unlike
+// readYarnPath it neither resolves nor checks the release.
+func yarnPathSetting(projectDirectory fs.AbsolutePath) string {
+	yarnRC := &util.YarnRC{}
+	bytes, err := projectDirectory.Join(".yarnrc.yml").ReadFile()
+	if err != nil || yaml.Unmarshal(normalizeLineEndings(bytes), yarnRC) != nil {
+		return ""
+	}
+	return yarnRC.YarnPath
+}
+
+// yarnLockfileVariant distinguishes berry from classic by the content of
+// yarn.lock, which may be written by either. The variant is unknown if the
+// lockfile is missing, empty, or has neither signature.
+func yarnLockfileVariant(projectDirectory fs.AbsolutePath) yarnVariant {
+	// Both variants share the lockfile name and its override, and referring
+	// to either of them here would be an initialization cycle.
+	head, err := PackageManager{Slug: "yarn", Lockfile: "yarn.lock"}.readLockfileHead(projectDirectory)
+	if err != nil {
+		return yarnVariantUnknown
+	}
+	head = normalizeLineEndings(head)
+	if _, ok := berryLockfileVersion(head); ok {
+		return yarnVariantBerry
+	}
+	if yarnClassicLockfileSignature(head) {
+		return yarnVariantClassic
+	}
+	return yarnVariantUnknown
+}
+
+// yarnClassicLockfileSignature reports whether head starts a lockfile written
+// by yarn classic.
+func yarnClassicLockfileSignature(head []byte) bool {
+	return bytes.Contains(head, []byte("# yarn lockfile v1"))
+}
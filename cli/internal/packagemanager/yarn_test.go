@@ -0,0 +1,114 @@
+package packagemanager
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func Test_detectYarnVariant(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "yarnrc.yml with yarnPath is berry",
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: node-modules\nyarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+			},
+			want: "nodejs-berry",
+		},
+		{
+			name: "yarn releases directory is berry",
+			files: map[string]string{
+				".yarnrc.yml":                   "nodeLinker: node-modules\n",
+				".yarn/releases/yarn-3.2.1.cjs": "",
+			},
+			want: "nodejs-berry",
+		},
+		{
+			name: "pnp.cjs is berry, which is unsupported",
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: pnp\n",
+				".pnp.cjs":    "",
+			},
+			wantErr: "only yarn nm-linker is supported",
+		},
+		{
+			name: "constraints files do not affect berry detection",
+			files: map[string]string{
+				".yarnrc.yml":     "nodeLinker: node-modules\nyarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+				"yarn.config.cjs": "module.exports = {};\n",
+				"constraints.pro": "",
+			},
+			want: "nodejs-berry",
+		},
+		{
+			name: "classic yarnrc is classic",
+			files: map[string]string{
+				".yarnrc": "--install.frozen-lockfile true\n",
+			},
+			want: "nodejs-yarn",
+		},
+		{
+			name: "berry signals win over a classic yarnrc",
+			files: map[string]string{
+				".yarnrc":     "--install.frozen-lockfile true\n",
+				".yarnrc.yml": "nodeLinker: node-modules\nyarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+			},
+			want: "nodejs-berry",
+		},
+		{
+			name: "berry lockfile is berry",
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: node-modules\n",
+				"yarn.lock":   "# This file is generated by running \"yarn install\" inside your project.\n\n__metadata:\n  version: 6\n  cacheKey: 8\n",
+			},
+			want: "nodejs-berry",
+		},
+		{
+			name: "berry lockfile wins over a classic yarnrc",
+			files: map[string]string{
+				".yarnrc":     "--install.frozen-lockfile true\n",
+				".yarnrc.yml": "nodeLinker: node-modules\n",
+				"yarn.lock":   "__metadata:\r\n  version: 4\r\n",
+			},
+			want: "nodejs-berry",
+		},
+		{
+			name: "classic lockfile is classic",
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: node-modules\n",
+				"yarn.lock":   "# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.\n# yarn lockfile v1\n\n\nreact@^18.0.0:\n  version \"18.2.0\"\n",
+			},
+			want: "nodejs-yarn",
+		},
+		{
+			name: "pinned berry release wins over a classic lockfile",
+			files: map[string]string{
+				".yarnrc.yml": "nodeLinker: node-modules\nyarnPath: .yarn/releases/yarn-3.2.1.cjs\n",
+				"yarn.lock":   "# yarn lockfile v1\n",
+			},
+			want: "nodejs-berry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.files["package.json"] = `{"name": "root"}`
+			if _, ok := tt.files["yarn.lock"]; !ok {
+				tt.files["yarn.lock"] = ""
+			}
+			rootPath := setupFixture(t, tt.files)
+
+			got, err := detectPackageManager(rootPath)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err, "detectPackageManager")
+			assert.Equal(t, got.Name, tt.want)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 type YarnRC struct {
 	NodeLinker string `yaml:"nodeLinker"`
+	YarnPath   string `yaml:"yarnPath"`
 }
 
 func IsYarn(backendName string) bool {